@@ -13,62 +13,61 @@ func IfBool[T any](v bool, ifTrue T, ifFalse T) T {
 
 // ToBool converts any data type to a bool, if the conversion fails, it returns false.
 func ToBool(v interface{}) bool {
+	b, _ := ToBoolE(v)
+	return b
+}
+
+// ToBoolE converts any data type to a bool, returning an error if the value cannot be converted.  Numbers are true
+// when they are non-zero.
+func ToBoolE(v interface{}) (bool, error) {
 	switch v := v.(type) {
 	case bool:
-		return v
+		return v, nil
 	case float32:
-		if v != 0.0 {
-			return true
-		}
+		return v != 0.0, nil
 	case float64:
-		if v != 0.0 {
-			return true
-		}
+		return v != 0.0, nil
 	case int:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case int8:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case int16:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case int32:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case int64:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case uint:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case uint8:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case uint16:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case uint32:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case uint64:
-		if v != 0 {
-			return true
-		}
+		return v != 0, nil
 	case string:
-		i, _ := strconv.ParseBool(v)
-		return i
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, strconvError(v, "bool", err)
+		}
+		return b, nil
+	default:
+		if cv, found, err := convertRegistered(v, boolType, stringType); found {
+			if err != nil {
+				return false, err
+			}
+			return ToBoolE(cv)
+		}
+		if bv, ok := namedBasic(v); ok {
+			return ToBoolE(bv)
+		}
+		if s, ok := textOf(v); ok {
+			return ToBoolE(s)
+		}
+		return false, newConversionError(v, "bool", ErrUnsupportedType)
 	}
-	return false
 }
 
 func ToBoolPtr(v interface{}) *bool {
@@ -1,44 +1,14 @@
 package tox
 
-import "strconv"
-
+// ToByte converts any data type to a byte, if the conversion fails, it returns 0.  Out of range values wrap.
 func ToByte(v interface{}) byte {
-	switch v := v.(type) {
-	case byte:
-		return v
-	case int:
-		return byte(v)
-	case int8:
-		return byte(v)
-	case int16:
-		return byte(v)
-	case int32:
-		return byte(v)
-	case int64:
-		return byte(v)
-	case uint:
-		return byte(v)
-	case uint16:
-		return byte(v)
-	case uint32:
-		return byte(v)
-	case uint64:
-		return byte(v)
-	case float32:
-		return byte(v)
-	case float64:
-		return byte(v)
-	case string:
-		i, _ := strconv.Atoi(v)
-		return byte(i)
-	case bool:
-		if v {
-			return 1
-		}
-		return 0
-	default:
-		return 0
-	}
+	b, _ := ToUint8Checked(v, OverflowWrap)
+	return b
+}
+
+// ToByteE converts any data type to a byte, returning an error if the value cannot be converted or does not fit.
+func ToByteE(v interface{}) (byte, error) {
+	return ToUint8Checked(v, OverflowError)
 }
 
 // ToByteArray converts bool, string, or byte arrays, if the conversion fails, it returns false.
@@ -0,0 +1,119 @@
+package tox
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConverterFunc converts a value of a registered type into the registered target type.
+type ConverterFunc func(v any) (any, error)
+
+// AnyType is the target type to register a converter for when a type should be stored differently inside an Object,
+// the converter is called by NewObject and ConvertStructs instead of expanding the value into a nested Object.
+var AnyType = reflect.TypeOf((*any)(nil)).Elem()
+
+var (
+	stringType  = reflect.TypeOf("")
+	boolType    = reflect.TypeOf(false)
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+)
+
+type converterKey struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+type converterMatch struct {
+	match func(reflect.Type) bool
+	to    reflect.Type
+	fn    ConverterFunc
+}
+
+var (
+	convertersLock   sync.RWMutex
+	converters       = map[converterKey]ConverterFunc{}
+	converterMatches []converterMatch
+)
+
+func init() {
+	// mongodb primitive.DateTime, milliseconds since the epoch
+	isDateTime := func(t reflect.Type) bool {
+		return t.Name() == "DateTime" && t.Kind() == reflect.Int64
+	}
+	RegisterConverterFunc(isDateTime, timeType, func(v any) (any, error) {
+		return time.Unix(reflect.ValueOf(v).Int()/1000, 0), nil
+	})
+	RegisterConverterFunc(isDateTime, stringType, func(v any) (any, error) {
+		return time.Unix(reflect.ValueOf(v).Int()/1000, 0).Format(time.RFC3339Nano), nil
+	})
+
+	// mongodb primitive.ObjectID, stored in an Object as its hex string
+	hexer := reflect.TypeOf((*interface{ Hex() string })(nil)).Elem()
+	RegisterConverterFunc(func(t reflect.Type) bool {
+		return strings.Contains(t.String(), "ObjectID") && t.Implements(hexer)
+	}, AnyType, func(v any) (any, error) {
+		return v.(interface{ Hex() string }).Hex(), nil
+	})
+}
+
+// RegisterConverter teaches tox how to convert values of type from into type to.  Registered converters are consulted
+// by ToString, ToInt, ToInt64, ToFloat64, ToBool, ToTime and ToE before the built in rules for types tox does not
+// already know.  Registering a converter to AnyType changes how the type is stored by NewObject.
+func RegisterConverter(from reflect.Type, to reflect.Type, fn ConverterFunc) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converters[converterKey{from: from, to: to}] = fn
+}
+
+// RegisterConverterFunc registers a converter for every type accepted by match, which is useful for families of types
+// that cannot be imported, like the mongodb driver types.  Converters registered with RegisterConverter take
+// precedence, and later matches take precedence over earlier ones.
+func RegisterConverterFunc(match func(reflect.Type) bool, to reflect.Type, fn ConverterFunc) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converterMatches = append(converterMatches, converterMatch{match: match, to: to, fn: fn})
+}
+
+// UnregisterConverter removes a converter registered with RegisterConverter.
+func UnregisterConverter(from reflect.Type, to reflect.Type) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	delete(converters, converterKey{from: from, to: to})
+}
+
+func lookupConverter(from reflect.Type, to reflect.Type) ConverterFunc {
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+	if fn, found := converters[converterKey{from: from, to: to}]; found {
+		return fn
+	}
+	for i := len(converterMatches) - 1; i >= 0; i-- {
+		if converterMatches[i].to == to && converterMatches[i].match(from) {
+			return converterMatches[i].fn
+		}
+	}
+	return nil
+}
+
+// convertRegistered runs the first converter registered for the type of v and one of the targets, in order.  found is
+// false if there is no such converter.
+func convertRegistered(v any, targets ...reflect.Type) (ret any, found bool, err error) {
+	if v == nil {
+		return nil, false, nil
+	}
+	from := reflect.TypeOf(v)
+	for _, to := range targets {
+		if fn := lookupConverter(from, to); fn != nil {
+			ret, err = fn(v)
+			if err == nil && reflect.TypeOf(ret) == from {
+				// a converter that hands back its own type would recurse forever
+				return nil, true, newConversionError(v, to.String(), ErrUnsupportedType)
+			}
+			return ret, true, err
+		}
+	}
+	return nil, false, nil
+}
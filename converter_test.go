@@ -0,0 +1,55 @@
+package tox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DateTime int64
+
+type money struct {
+	Cents int64
+}
+
+func TestRegisteredConverters(t *testing.T) {
+	moneyType := reflect.TypeOf(money{})
+	RegisterConverter(moneyType, float64Type, func(v any) (any, error) {
+		return float64(v.(money).Cents) / 100, nil
+	})
+	RegisterConverter(moneyType, stringType, func(v any) (any, error) {
+		return ToStringOpts(float64(v.(money).Cents)/100, &Options{FloatPrecision: 2}), nil
+	})
+	RegisterConverter(moneyType, AnyType, func(v any) (any, error) {
+		return v.(money).Cents, nil
+	})
+	defer UnregisterConverter(moneyType, float64Type)
+	defer UnregisterConverter(moneyType, stringType)
+	defer UnregisterConverter(moneyType, AnyType)
+
+	m := money{Cents: 1250}
+	assert.Equal(t, 12.5, ToFloat64(m))
+	assert.Equal(t, "12.50", ToString(m))
+	assert.Equal(t, "12.50", ToString(&m))
+	assert.Equal(t, 12, ToInt(m))
+	assert.Equal(t, 12.5, To[float64](m))
+	assert.Equal(t, int64(1250), NewObject(map[string]any{"price": m}).Get("price"))
+
+	failing := reflect.TypeOf(struct{ X int }{})
+	boom := errors.New("boom")
+	RegisterConverter(failing, boolType, func(v any) (any, error) {
+		return nil, boom
+	})
+	defer UnregisterConverter(failing, boolType)
+	_, err := ToBoolE(struct{ X int }{})
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestDefaultDateTimeConverter(t *testing.T) {
+	dt := DateTime(1700000000000)
+	assert.Equal(t, time.Unix(1700000000, 0), ToTime(dt))
+	assert.Equal(t, time.Unix(1700000000, 0).Format(time.RFC3339Nano), ToString(dt))
+}
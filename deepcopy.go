@@ -147,9 +147,7 @@ func _map(x any, ptrs map[uintptr]any) (any, error) {
 			return nil, fmt.Errorf("failed to clone the map key %v: %v", k, err)
 		}
 		if item == nil {
-			if mi, ok := dc.Interface().(map[string]any); ok {
-				mi[ValueOf(k).String()] = nil
-			}
+			dc.SetMapIndex(ValueOf(k), Zero(t.Elem()))
 		} else {
 			dc.SetMapIndex(ValueOf(k), ValueOf(item))
 		}
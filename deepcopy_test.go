@@ -38,3 +38,15 @@ func TestDeepcopyTime(t *testing.T) {
 		t.Errorf("expected nil for nil_time")
 	}
 }
+
+func TestDeepcopyNilValues(t *testing.T) {
+	o := Object{"a": nil, "b": Object{"c": nil}}
+
+	cp := o.Clone()
+	if v, found := cp["a"]; !found || v != nil {
+		t.Errorf("expected nil for a, got %v (found %v)", v, found)
+	}
+	if v, found := cp["b"].(Object)["c"]; !found || v != nil {
+		t.Errorf("expected nil for b.c, got %v (found %v)", v, found)
+	}
+}
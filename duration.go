@@ -0,0 +1,463 @@
+package tox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond, // U+00B5 micro sign
+	"μs": time.Microsecond, // U+03BC greek mu
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+	"w":  Week,
+
+	// the long names written by ToPrettyDuration
+	"nanosecond":   time.Nanosecond,
+	"nanoseconds":  time.Nanosecond,
+	"microsecond":  time.Microsecond,
+	"microseconds": time.Microsecond,
+	"millisecond":  time.Millisecond,
+	"milliseconds": time.Millisecond,
+	"second":       time.Second,
+	"seconds":      time.Second,
+	"minute":       time.Minute,
+	"minutes":      time.Minute,
+	"hour":         time.Hour,
+	"hours":        time.Hour,
+	"day":          Day,
+	"days":         Day,
+	"week":         Week,
+	"weeks":        Week,
+}
+
+// ParseDuration parses a duration string.  It accepts Go style durations with any number of fractional or integer
+// components ("1h30m", "1.5h", "-2w3d"), the d (day) and w (week) units, long unit names ("2 hours"), components
+// separated by spaces ("1d 12h") and ISO-8601 durations ("P1DT2H", years and months are counted as 365 and 30
+// days).  A plain number is treated as seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+	}
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = strings.TrimSpace(s[1:])
+	}
+
+	var d time.Duration
+	var err error
+	if f, ferr := strconv.ParseFloat(s, 64); ferr == nil {
+		d, err = scaleDuration(f, time.Second)
+	} else if strings.HasPrefix(s, "p") {
+		d, err = parseISODuration(s[1:])
+	} else {
+		d, err = parseGoDuration(s)
+	}
+	if err != nil {
+		return 0, newConversionError(orig, "time.Duration", err)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseGoDuration parses a sequence of number and unit pairs, the same syntax as time.ParseDuration plus days, weeks
+// and spaces between the components.
+func parseGoDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		n := 0
+		for n < len(s) && (s[n] == '.' || (s[n] >= '0' && s[n] <= '9')) {
+			n++
+		}
+		if n == 0 {
+			return 0, ErrSyntax
+		}
+		num := s[:n]
+		s = strings.TrimLeft(s[n:], " ")
+		u := 0
+		for u < len(s) && s[u] != '.' && s[u] != ' ' && (s[u] < '0' || s[u] > '9') {
+			u++
+		}
+		unit, found := durationUnits[s[:u]]
+		if !found {
+			return 0, ErrSyntax
+		}
+		s = s[u:]
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, ErrSyntax
+		}
+		d, err := scaleDuration(f, unit)
+		if err != nil {
+			return 0, err
+		}
+		if total, err = addDuration(total, d); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// parseISODuration parses the part of an ISO-8601 duration after the P.
+func parseISODuration(s string) (time.Duration, error) {
+	if s == "" || s == "t" {
+		return 0, ErrSyntax
+	}
+	var total time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 't' {
+			if inTime {
+				return 0, ErrSyntax
+			}
+			inTime = true
+			s = s[1:]
+			if s == "" {
+				return 0, ErrSyntax
+			}
+			continue
+		}
+		n := 0
+		for n < len(s) && (s[n] == '.' || s[n] == ',' || (s[n] >= '0' && s[n] <= '9')) {
+			n++
+		}
+		if n == 0 || n == len(s) {
+			return 0, ErrSyntax
+		}
+		f, err := strconv.ParseFloat(strings.Replace(s[:n], ",", ".", 1), 64)
+		if err != nil {
+			return 0, ErrSyntax
+		}
+		var unit time.Duration
+		switch designator := s[n]; {
+		case !inTime && designator == 'y':
+			unit = 365 * Day
+		case !inTime && designator == 'm':
+			unit = 30 * Day
+		case !inTime && designator == 'w':
+			unit = Week
+		case !inTime && designator == 'd':
+			unit = Day
+		case inTime && designator == 'h':
+			unit = time.Hour
+		case inTime && designator == 'm':
+			unit = time.Minute
+		case inTime && designator == 's':
+			unit = time.Second
+		default:
+			return 0, ErrSyntax
+		}
+		s = s[n+1:]
+		d, err := scaleDuration(f, unit)
+		if err != nil {
+			return 0, err
+		}
+		if total, err = addDuration(total, d); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+func scaleDuration(f float64, unit time.Duration) (time.Duration, error) {
+	d := f * float64(unit)
+	if math.IsNaN(d) || d >= math.MaxInt64 || d <= math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return time.Duration(math.Round(d)), nil
+}
+
+func addDuration(a time.Duration, b time.Duration) (time.Duration, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+// ToDuration converts any data type to a time.Duration.  Numbers are counted in seconds, strings are parsed with
+// ParseDuration.  If the conversion fails, it returns 0.
+func ToDuration(v any) time.Duration {
+	return ToDurationOpts(v, nil)
+}
+
+// ToDurationE converts any data type to a time.Duration the same way as ToDuration, returning an error if the value
+// cannot be converted.
+func ToDurationE(v any) (time.Duration, error) {
+	return ToDurationOptsE(v, nil)
+}
+
+// ToDurationOpts converts any data type to a time.Duration, numbers and numeric strings are counted in
+// options.DurationUnit.
+func ToDurationOpts(v any, options *Options) time.Duration {
+	d, _ := ToDurationOptsE(v, options)
+	return d
+}
+
+func ToDurationOptsE(v any, options *Options) (time.Duration, error) {
+	unit := time.Second
+	if options != nil && options.DurationUnit > 0 {
+		unit = options.DurationUnit
+	}
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		n, err := ToInt64E(v)
+		if err != nil {
+			return 0, err
+		}
+		if n != 0 && (n*int64(unit))/int64(unit) != n {
+			return 0, newConversionError(v, "time.Duration", ErrOverflow)
+		}
+		return time.Duration(n) * unit, nil
+	case float32, float64:
+		d, err := scaleDuration(ToFloat64(v), unit)
+		if err != nil {
+			return 0, newConversionError(v, "time.Duration", err)
+		}
+		return d, nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			d, err := scaleDuration(f, unit)
+			if err != nil {
+				return 0, newConversionError(v, "time.Duration", err)
+			}
+			return d, nil
+		}
+		return ParseDuration(v)
+	case nil:
+		return 0, newConversionError(v, "time.Duration", ErrUnsupportedType)
+	default:
+		if cv, found, err := convertRegistered(v, durationType, stringType); found {
+			if err != nil {
+				return 0, err
+			}
+			return ToDurationOptsE(cv, options)
+		}
+		if bv, ok := namedBasic(v); ok {
+			return ToDurationOptsE(bv, options)
+		}
+		if s, ok := textOf(v); ok {
+			return ToDurationOptsE(s, options)
+		}
+		return 0, newConversionError(v, "time.Duration", ErrUnsupportedType)
+	}
+}
+
+type StringFormat int
+
+const (
+	// FormatShort is a zero padded clock, "1.02:03:04" for days, hours, minutes and seconds.
+	FormatShort StringFormat = 1
+	// FormatMedium lists the non-zero units, "1d, 2h, 3m, 4s".
+	FormatMedium StringFormat = 2
+	// FormatLong lists the non-zero units by name, "1 day, 2 hours, 3 minutes, 4 seconds".
+	FormatLong StringFormat = 3
+	// FormatISO8601 is an ISO-8601 duration, "P1DT2H3M4S".
+	FormatISO8601 StringFormat = 4
+)
+
+// ToPrettyDuration formats a duration to the second, any fraction of a second is dropped.
+func ToPrettyDuration(v time.Duration, format StringFormat) string {
+	return ToPrettyDurationPrecision(v, format, time.Second)
+}
+
+// ToPrettyDurationPrecision formats a duration truncated to precision, which is one of time.Second,
+// time.Millisecond, time.Microsecond or time.Nanosecond.  Every format can be read back with ParsePrettyDuration.
+func ToPrettyDurationPrecision(v time.Duration, format StringFormat, precision time.Duration) string {
+	isNegative := false
+	// work in uint64 so math.MinInt64 can be negated
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-v)
+		isNegative = true
+	}
+	var digits int
+	switch precision {
+	case time.Millisecond:
+		digits = 3
+	case time.Microsecond:
+		digits = 6
+	case time.Nanosecond:
+		digits = 9
+	default:
+		precision = time.Second
+	}
+	u -= u % uint64(precision)
+	if u == 0 {
+		isNegative = false
+	}
+
+	days := u / uint64(Day)
+	u -= days * uint64(Day)
+	hours := u / uint64(time.Hour)
+	u -= hours * uint64(time.Hour)
+	minutes := u / uint64(time.Minute)
+	u -= minutes * uint64(time.Minute)
+	seconds := u / uint64(time.Second)
+	nanos := u - seconds*uint64(time.Second)
+
+	var ret string
+	switch format {
+	case FormatMedium, FormatLong:
+		type unit struct {
+			n     uint64
+			short string
+			long  string
+		}
+		units := []unit{
+			{days, "d", "day"},
+			{hours, "h", "hour"},
+			{minutes, "m", "minute"},
+			{seconds, "s", "second"},
+		}
+		if digits >= 3 {
+			units = append(units, unit{nanos / 1e6, "ms", "millisecond"})
+		}
+		if digits >= 6 {
+			units = append(units, unit{nanos / 1e3 % 1e3, "us", "microsecond"})
+		}
+		if digits >= 9 {
+			units = append(units, unit{nanos % 1e3, "ns", "nanosecond"})
+		}
+		var r []string
+		for _, un := range units {
+			if un.n == 0 {
+				continue
+			}
+			if format == FormatMedium {
+				r = append(r, fmt.Sprintf("%d%s", un.n, un.short))
+			} else {
+				r = append(r, fmt.Sprintf("%d %s", un.n, pluralize(un.long, un.n)))
+			}
+		}
+		if len(r) == 0 {
+			if format == FormatMedium {
+				r = []string{"0s"}
+			} else {
+				r = []string{"0 seconds"}
+			}
+		}
+		ret = strings.Join(r, ", ")
+	case FormatISO8601:
+		ret = "P"
+		if days > 0 {
+			ret += fmt.Sprintf("%dD", days)
+		}
+		if hours > 0 || minutes > 0 || seconds > 0 || nanos > 0 || days == 0 {
+			ret += "T"
+			if hours > 0 {
+				ret += fmt.Sprintf("%dH", hours)
+			}
+			if minutes > 0 {
+				ret += fmt.Sprintf("%dM", minutes)
+			}
+			if seconds > 0 || nanos > 0 || (hours == 0 && minutes == 0) {
+				ret += fmt.Sprintf("%d%sS", seconds, fraction(nanos, digits, true))
+			}
+		}
+	default:
+		ret = fmt.Sprintf("%d.%02d:%02d:%02d%s", days, hours, minutes, seconds, fraction(nanos, digits, false))
+	}
+	if isNegative {
+		ret = "-" + ret
+	}
+	return ret
+}
+
+func pluralize(s string, n uint64) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
+
+// fraction formats nanos as a fraction of a second with the given number of digits, optionally trimming trailing
+// zeros.
+func fraction(nanos uint64, digits int, trim bool) string {
+	if digits == 0 {
+		return ""
+	}
+	f := fmt.Sprintf("%09d", nanos)[:digits]
+	if trim {
+		f = strings.TrimRight(f, "0")
+		if f == "" {
+			return ""
+		}
+	}
+	return "." + f
+}
+
+// ParsePrettyDuration parses any of the formats written by ToPrettyDuration, as well as everything ParseDuration
+// accepts.
+func ParsePrettyDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, ":") {
+		return ParseDuration(strings.ReplaceAll(s, ",", " "))
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+	}
+	var days, hours uint64
+	var err error
+	if dot := strings.IndexByte(parts[0], '.'); dot != -1 {
+		if days, err = strconv.ParseUint(parts[0][:dot], 10, 64); err != nil {
+			return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+		}
+		parts[0] = parts[0][dot+1:]
+	}
+	if hours, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+	}
+	minutes, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || minutes > 59 {
+		return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || seconds < 0 || seconds >= 60 || strings.ContainsAny(parts[2], "eE+-") {
+		return 0, newConversionError(orig, "time.Duration", ErrSyntax)
+	}
+	d := time.Duration(days)*Day + time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if d < 0 || days > uint64(math.MaxInt64/Day) {
+		return 0, newConversionError(orig, "time.Duration", ErrOverflow)
+	}
+	secs, err := scaleDuration(seconds, time.Second)
+	if err != nil {
+		return 0, newConversionError(orig, "time.Duration", err)
+	}
+	if d, err = addDuration(d, secs); err != nil {
+		return 0, newConversionError(orig, "time.Duration", err)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,96 @@
+package tox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"500ms":        500 * time.Millisecond,
+		"10":           10 * time.Second,
+		"1h30m":        90 * time.Minute,
+		"1.5h":         90 * time.Minute,
+		"1d12h":        36 * time.Hour,
+		"1d 12h":       36 * time.Hour,
+		"2w":           14 * Day,
+		"-1m30s":       -90 * time.Second,
+		"250us":        250 * time.Microsecond,
+		"3µs":          3 * time.Microsecond,
+		"P1DT2H":       26 * time.Hour,
+		"PT1M30.5S":    90*time.Second + 500*time.Millisecond,
+		"P2W":          14 * Day,
+		"-PT5M":        -5 * time.Minute,
+		"P1Y":          365 * Day,
+		"  1H  ":       time.Hour,
+		"1.5":          1500 * time.Millisecond,
+		"1 h 30 m 5 s": 90*time.Minute + 5*time.Second,
+	}
+	for in, want := range tests {
+		got, err := ParseDuration(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "abc", "1x", "P", "PT", "P1H", "1h30", "100000000w"} {
+		_, err := ParseDuration(in)
+		assert.Error(t, err, in)
+	}
+	_, err := ParseDuration("100000000w")
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestToDuration(t *testing.T) {
+	assert.Equal(t, 5*time.Second, ToDuration(5))
+	assert.Equal(t, 1500*time.Millisecond, ToDuration(1.5))
+	assert.Equal(t, 36*time.Hour, ToDuration("1d12h"))
+	assert.Equal(t, time.Minute, ToDuration(time.Minute))
+	assert.Equal(t, 5*time.Millisecond, ToDurationOpts("5", &Options{DurationUnit: time.Millisecond}))
+	assert.Equal(t, 5*time.Millisecond, ToDurationOpts(int64(5), &Options{DurationUnit: time.Millisecond}))
+	assert.Equal(t, 2*time.Hour, To[time.Duration]("2h"))
+
+	_, err := ToDurationE(true)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	o := Object{"timeout": "1d12h", "retry": 30}
+	assert.Equal(t, 36*time.Hour, o.GetDuration("timeout", 0))
+	assert.Equal(t, 30*time.Second, o.GetDuration("retry", 0))
+	assert.Equal(t, time.Minute, o.GetDuration("missing", time.Minute))
+}
+
+func TestPrettyDuration(t *testing.T) {
+	d := 26*time.Hour + 3*time.Minute + 4*time.Second + 567890*time.Microsecond
+
+	assert.Equal(t, "1.02:03:04", ToPrettyDuration(d, FormatShort))
+	assert.Equal(t, "1.02:03:04.567", ToPrettyDurationPrecision(d, FormatShort, time.Millisecond))
+	assert.Equal(t, "1d, 2h, 3m, 4s", ToPrettyDuration(d, FormatMedium))
+	assert.Equal(t, "1d, 2h, 3m, 4s, 567ms, 890us", ToPrettyDurationPrecision(d, FormatMedium, time.Microsecond))
+	assert.Equal(t, "1 day, 2 hours, 3 minutes, 4 seconds", ToPrettyDuration(d, FormatLong))
+	assert.Equal(t, "1 minute, 1 second", ToPrettyDuration(61*time.Second, FormatLong))
+	assert.Equal(t, "P1DT2H3M4S", ToPrettyDuration(d, FormatISO8601))
+	assert.Equal(t, "P1DT2H3M4.56789S", ToPrettyDurationPrecision(d, FormatISO8601, time.Microsecond))
+	assert.Equal(t, "-0.00:01:30", ToPrettyDuration(-90*time.Second, FormatShort))
+	assert.Equal(t, "0 seconds", ToPrettyDuration(0, FormatLong))
+	assert.Equal(t, "PT0S", ToPrettyDuration(0, FormatISO8601))
+	assert.Equal(t, "P2D", ToPrettyDuration(2*Day, FormatISO8601))
+	assert.Equal(t, "0.00:00:01", ToString(time.Second))
+
+	for _, v := range []time.Duration{0, time.Second, -90 * time.Second, d, 400*Day + 1, -d} {
+		for _, format := range []StringFormat{FormatShort, FormatMedium, FormatLong, FormatISO8601} {
+			for _, precision := range []time.Duration{time.Second, time.Millisecond, time.Microsecond, time.Nanosecond} {
+				s := ToPrettyDurationPrecision(v, format, precision)
+				back, err := ParsePrettyDuration(s)
+				assert.NoError(t, err, s)
+				want := v - v%precision
+				assert.Equal(t, want, back, s)
+			}
+		}
+	}
+
+	_, err := ParsePrettyDuration("1.02:75:00")
+	assert.ErrorIs(t, err, ErrSyntax)
+	_, err = ParsePrettyDuration("3 fortnights")
+	assert.ErrorIs(t, err, ErrSyntax)
+}
@@ -0,0 +1,235 @@
+package tox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// EqualOptions controls Equal.
+type EqualOptions struct {
+	// NormalizeNumbers compares numbers by value whatever their type, so int 1, float64 1 and uint8 1 are equal.
+	// Strings are never numbers.
+	NormalizeNumbers bool
+	// NilEqualsMissing treats a key whose value is nil like a missing key.
+	NilEqualsMissing bool
+	// NaNEqual treats NaN as equal to NaN.
+	NaNEqual bool
+}
+
+// defaultEqualOptions are the options of Object.Equals and Object.Hash.
+var defaultEqualOptions = EqualOptions{NormalizeNumbers: true, NaNEqual: true}
+
+// Equals compares the object to other structurally, with numbers compared by value and NaN equal to NaN.  Object and
+// map[string]any are interchangeable, as are []any and typed slices with equal elements.
+func (o Object) Equals(other Object) bool {
+	return o.EqualsWith(other, defaultEqualOptions)
+}
+
+// EqualsWith compares the object to other structurally, as options say.
+func (o Object) EqualsWith(other Object, options EqualOptions) bool {
+	if o == nil || other == nil {
+		return o == nil && other == nil
+	}
+	return Equal(o, other, options)
+}
+
+// Equal compares two values structurally, walking maps with string keys, whether Object, map[string]any or
+// another map type, and slices and arrays of any type.  Times are equal when they are the same instant.
+func Equal(a any, b any, options EqualOptions) bool {
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isStringMap(ra) && isStringMap(rb) {
+		return equalMaps(ra, rb, options)
+	}
+	if isList(ra) && isList(rb) {
+		if ra.Len() != rb.Len() {
+			return false
+		}
+		for i := 0; i < ra.Len(); i++ {
+			if !Equal(ra.Index(i).Interface(), rb.Index(i).Interface(), options) {
+				return false
+			}
+		}
+		return true
+	}
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	if isNumber(ra) && isNumber(rb) {
+		if !options.NormalizeNumbers && ra.Type() != rb.Type() {
+			return false
+		}
+		return equalNumbers(ra, rb, options.NaNEqual)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isStringMap(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String
+}
+
+func isNumber(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func equalMaps(ra reflect.Value, rb reflect.Value, options EqualOptions) bool {
+	present := func(rv reflect.Value, key reflect.Value) (reflect.Value, bool) {
+		v := rv.MapIndex(key.Convert(rv.Type().Key()))
+		if !v.IsValid() || (options.NilEqualsMissing && v.Interface() == nil) {
+			return v, false
+		}
+		return v, true
+	}
+	count := func(rv reflect.Value) int {
+		n := 0
+		for _, k := range rv.MapKeys() {
+			if _, ok := present(rv, k); ok {
+				n++
+			}
+		}
+		return n
+	}
+	if count(ra) != count(rb) {
+		return false
+	}
+	for _, k := range ra.MapKeys() {
+		av, aok := present(ra, k)
+		if !aok {
+			continue
+		}
+		bv, bok := present(rb, k)
+		if !bok || !Equal(av.Interface(), bv.Interface(), options) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalNumbers compares two numbers exactly, so large integers are not rounded through float64.
+func equalNumbers(ra reflect.Value, rb reflect.Value, nanEqual bool) bool {
+	a, aok := exactInt(ra)
+	b, bok := exactInt(rb)
+	if aok && bok {
+		return a.sign == b.sign && a.abs == b.abs
+	}
+	x, y := numberFloat(ra), numberFloat(rb)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return nanEqual && math.IsNaN(x) && math.IsNaN(y)
+	}
+	return x == y
+}
+
+type integer struct {
+	sign bool
+	abs  uint64
+}
+
+// exactInt returns a number as a sign and magnitude if it is an integer, including an integral float, where -0 is 0.
+func exactInt(rv reflect.Value) (integer, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n < 0 {
+			return integer{sign: true, abs: uint64(-(n + 1)) + 1}, true
+		} else {
+			return integer{abs: uint64(n)}, true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return integer{abs: rv.Uint()}, true
+	default:
+		f := rv.Float()
+		if f != math.Trunc(f) || math.Abs(f) >= 1<<64 {
+			return integer{}, false
+		}
+		return integer{sign: f < 0, abs: uint64(math.Abs(f))}, true
+	}
+}
+
+func numberFloat(rv reflect.Value) float64 {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	default:
+		return rv.Float()
+	}
+}
+
+// Hash returns a stable SHA-256 hash of the content of the object, as a hex string.  Objects that are Equals have
+// the same hash: keys are hashed in sorted order, Object and map[string]any hash alike, as do []any and typed
+// slices, and numbers hash by value.
+func (o Object) Hash() string {
+	h := sha256.New()
+	writeHash(h, map[string]any(o))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHash writes a canonical encoding of v, where every value is tagged with its kind and strings are prefixed
+// with their length, so different values cannot encode alike.
+func writeHash(w io.Writer, v any) {
+	writeString := func(tag string, s string) {
+		_, _ = io.WriteString(w, tag+strconv.Itoa(len(s))+":"+s)
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		_, _ = io.WriteString(w, "z")
+	case isStringMap(rv):
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		_, _ = io.WriteString(w, "m"+strconv.Itoa(len(keys))+"{")
+		for _, k := range keys {
+			writeString("k", k)
+			writeHash(w, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface())
+		}
+		_, _ = io.WriteString(w, "}")
+	case isList(rv):
+		_, _ = io.WriteString(w, "l"+strconv.Itoa(rv.Len())+"[")
+		for i := 0; i < rv.Len(); i++ {
+			writeHash(w, rv.Index(i).Interface())
+		}
+		_, _ = io.WriteString(w, "]")
+	case isNumber(rv):
+		if n, ok := exactInt(rv); ok {
+			sign := ""
+			if n.sign {
+				sign = "-"
+			}
+			writeString("n", sign+strconv.FormatUint(n.abs, 10))
+		} else {
+			writeString("n", strconv.FormatFloat(numberFloat(rv), 'g', -1, 64))
+		}
+	case rv.Kind() == reflect.String:
+		writeString("s", rv.String())
+	case rv.Kind() == reflect.Bool:
+		writeString("b", strconv.FormatBool(rv.Bool()))
+	default:
+		if t, ok := v.(time.Time); ok {
+			writeString("t", t.UTC().Format(time.RFC3339Nano))
+			return
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(ToString(v))
+		}
+		writeString("j", string(b))
+	}
+}
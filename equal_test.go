@@ -0,0 +1,49 @@
+package tox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquals(t *testing.T) {
+	a := Object{"a": 1, "b": []any{"x", 2.0}, "c": map[string]any{"d": math.NaN()}}
+	b := Object{"a": 1.0, "b": []string{"x", "y"}, "c": Object{"d": math.NaN()}}
+	assert.False(t, a.Equals(b))
+	b["b"] = []any{"x", uint8(2)}
+	assert.True(t, a.Equals(b))
+	assert.False(t, a.EqualsWith(b, EqualOptions{NaNEqual: true}), "int and float differ without NormalizeNumbers")
+	assert.False(t, a.EqualsWith(b, EqualOptions{NormalizeNumbers: true}), "NaN differs without NaNEqual")
+	assert.True(t, Equal([]string{"a"}, []any{"a"}, EqualOptions{}))
+	assert.False(t, Equal("1", 1, defaultEqualOptions))
+	assert.True(t, Equal(int64(math.MaxInt64), uint64(math.MaxInt64), defaultEqualOptions))
+	assert.False(t, Equal(int64(math.MaxInt64), uint64(math.MaxInt64)-1, defaultEqualOptions))
+
+	c, d := Object{"a": 1, "b": nil}, Object{"a": 1}
+	assert.False(t, c.Equals(d))
+	assert.True(t, c.EqualsWith(d, EqualOptions{NilEqualsMissing: true}))
+	assert.True(t, d.EqualsWith(c, EqualOptions{NilEqualsMissing: true}))
+
+	var n Object
+	assert.False(t, n.Equals(Object{}))
+	assert.True(t, n.Equals(nil))
+}
+
+func TestHash(t *testing.T) {
+	a := Object{"a": 1, "b": []any{"x", 2.0}, "c": map[string]any{"d": true}}
+	b := Object{"c": Object{"d": true}, "b": []any{"x", int8(2)}, "a": uint(1)}
+	assert.Equal(t, a.Hash(), b.Hash())
+	zero, negZero := Object{"a": 0}, Object{"a": math.Copysign(0, -1)}
+	assert.True(t, zero.Equals(negZero))
+	assert.Equal(t, zero.Hash(), negZero.Hash())
+	assert.Len(t, a.Hash(), 64)
+	for _, o := range []Object{
+		{"a": "1", "b": []any{"x", 2.0}, "c": map[string]any{"d": true}},
+		{"a": 1, "b": []any{"x2.0"}, "c": map[string]any{"d": true}},
+		{"a": 1, "b": []any{"x", 2.0}, "c": map[string]any{"d": true, "e": nil}},
+		{"a": 1, "b": []any{"x", 2.5}, "c": map[string]any{"d": true}},
+	} {
+		assert.NotEqual(t, a.Hash(), o.Hash(), o)
+	}
+}
@@ -0,0 +1,48 @@
+package tox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrUnsupportedType is returned when the source type has no conversion to the requested type.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrSyntax is returned when a string value cannot be parsed as the requested type.
+	ErrSyntax = errors.New("invalid syntax")
+	// ErrOverflow is returned when a value does not fit in the requested type.
+	ErrOverflow = errors.New("value out of range")
+	// ErrTruncated is returned when converting a float to an integer would drop its fractional part.
+	ErrTruncated = errors.New("fractional part truncated")
+	// ErrNaN is returned when a NaN float is converted to an integer.
+	ErrNaN = errors.New("value is NaN")
+)
+
+// ConversionError records a failed conversion, the value and type it started from and the type requested.
+type ConversionError struct {
+	Value any
+	From  string
+	To    string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("tox: cannot convert %v (%s) to %s: %v", e.Value, e.From, e.To, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+func newConversionError(v any, to string, err error) error {
+	return &ConversionError{Value: v, From: fmt.Sprintf("%T", v), To: to, Err: err}
+}
+
+// strconvError maps errors from the strconv package onto ErrSyntax or ErrOverflow.
+func strconvError(v any, to string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return newConversionError(v, to, ErrOverflow)
+	}
+	return newConversionError(v, to, ErrSyntax)
+}
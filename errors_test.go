@@ -0,0 +1,56 @@
+package tox
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversionErrors(t *testing.T) {
+	i, err := ToIntE("42")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, i)
+
+	_, err = ToIntE("abc")
+	assert.ErrorIs(t, err, ErrSyntax)
+	var convErr *ConversionError
+	assert.True(t, errors.As(err, &convErr))
+	assert.Equal(t, "string", convErr.From)
+	assert.Equal(t, "int", convErr.To)
+	assert.Equal(t, "abc", convErr.Value)
+
+	_, err = ToInt64E("99999999999999999999")
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = ToIntE(struct{}{})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = ToFloat64E("x")
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.True(t, math.IsNaN(ToFloat64("x")))
+
+	b, err := ToBoolE("true")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	_, err = ToBoolE("maybe")
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.False(t, ToBool("maybe"))
+
+	_, err = ToByteE([]int{1})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = ToTimeE("yesterday")
+	assert.ErrorIs(t, err, ErrSyntax)
+	_, err = ToTimeE(nil)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Equal(t, time.Time{}, ToTime(nil))
+
+	s, err := ToStringE(12)
+	assert.NoError(t, err)
+	assert.Equal(t, "12", s)
+	_, err = ToStringE(func() {})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
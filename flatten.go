@@ -0,0 +1,344 @@
+package tox
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrFlattenConflict is returned by Unflatten when two flat keys need the same path to be both a value and a container,
+// such as "a"=1 and "a.b"=2, or both an Object and an array, such as "a.b"=1 and "a[0]"=2.
+var ErrFlattenConflict = errors.New("conflicting flattened keys")
+
+// IndexNotation is how Flatten writes array indexes.
+type IndexNotation int
+
+const (
+	// IndexBrackets writes array indexes in brackets, as in "a.b[0].c".
+	IndexBrackets IndexNotation = iota
+	// IndexDotted writes array indexes as keys, as in "a.b.0.c".  With Escape, map keys made only of digits are
+	// escaped, as in "a.\0", so they are not read back as indexes.
+	IndexDotted
+)
+
+// FlattenOptions controls FlattenWith and UnflattenWith.
+type FlattenOptions struct {
+	// Delim separates keys, "." when empty.
+	Delim string
+	// Indexes is how array indexes are written.
+	Indexes IndexNotation
+	// MaxDepth is the most keys and indexes a flat key may have, deeper Objects and arrays are kept whole as values.
+	// Zero means no limit.
+	MaxDepth int
+	// KeepEmpty keeps empty Objects and arrays as values, otherwise they are dropped and Unflatten cannot restore them.
+	KeepEmpty bool
+	// Leaf, when set, is called with the flat key of every Object and array below the top, which is kept whole as a
+	// value when it returns true.
+	Leaf func(path string, value any) bool
+	// Escape escapes keys that contain the delimiter, brackets or backslashes, as JoinPath does, so UnflattenWith with
+	// the same options rebuilds them exactly.  Otherwise keys are joined as they are, and Unflatten splits them at
+	// every delimiter and reads any [n] at the end of a key as an index.
+	Escape bool
+	// Key, when set, transforms every map key before it is escaped and joined.  Keys it maps together overwrite each
+	// other, and Unflatten cannot restore the originals.
+	Key func(key string) string
+}
+
+func (options FlattenOptions) delim() string {
+	if options.Delim == "" {
+		return "."
+	}
+	return options.Delim
+}
+
+// FlattenWith flattens nested Objects and arrays into a single Object whose keys are the paths to each value, as
+// options say.  With Escape set, UnflattenWith with the same options rebuilds the object exactly.
+func (o Object) FlattenWith(options FlattenOptions) Object {
+	output := Object{}
+	flattenLeaves(o, options, func(path string, v any) {
+		output[path] = v
+	})
+	return output
+}
+
+// WalkLeaves calls fn with the flat key and value of every leaf, in sorted key order, as Flatten(".") would return
+// them but without building the flattened object.
+func (o Object) WalkLeaves(fn func(path string, v any)) {
+	flattenLeaves(o, FlattenOptions{KeepEmpty: true}, fn)
+}
+
+// WalkLeavesWith is WalkLeaves with the keys FlattenWith would return for options.
+func (o Object) WalkLeavesWith(options FlattenOptions, fn func(path string, v any)) {
+	flattenLeaves(o, options, fn)
+}
+
+// flattenLeaves is the engine behind FlattenWith, FlattenMap and WalkLeaves, calling fn for every leaf Walk finds.
+func flattenLeaves(m map[string]any, options FlattenOptions, fn func(path string, v any)) {
+	Object(m).Walk(func(path Path, v any) WalkAction {
+		key := options.flatKey(path)
+		n, container := containerLen(v)
+		switch {
+		case !container || options.MaxDepth > 0 && len(path) >= options.MaxDepth ||
+			options.Leaf != nil && options.Leaf(key, v):
+			fn(key, v)
+			return WalkSkip
+		case n == 0 && options.KeepEmpty:
+			fn(key, v)
+		}
+		return WalkContinue
+	})
+}
+
+// flatKey returns the flat key of the value at path.
+func (options FlattenOptions) flatKey(path Path) string {
+	delim := options.delim()
+	var sb strings.Builder
+	for i, seg := range path {
+		switch {
+		case seg.IsIndex && options.Indexes == IndexDotted:
+			sb.WriteString(delim + strconv.Itoa(seg.Index))
+		case seg.IsIndex:
+			sb.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+		default:
+			k := seg.Key
+			if options.Key != nil {
+				k = options.Key(k)
+			}
+			if options.Escape {
+				k = escapePathKey(k, delim)
+				if options.Indexes == IndexDotted && isDigits(k) {
+					k = `\` + k
+				}
+			}
+			if i > 0 {
+				sb.WriteString(delim)
+			}
+			sb.WriteString(k)
+		}
+	}
+	return sb.String()
+}
+
+// containerLen returns the length of a map with string keys, slice or array, and false for any other value.
+func containerLen(v any) (int, bool) {
+	if m, ok := mapOf(v); ok {
+		return len(m), true
+	}
+	rv := reflect.ValueOf(v)
+	if isStringMap(rv) || rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+// Unflatten rebuilds nested Objects and arrays from keys such as "a.b[0].c", splitting them at every delim and reading
+// any [n] at the end of a key as an array index.  Missing array indexes are filled with nil, and keys that conflict
+// return an error wrapping ErrFlattenConflict.  It only reverses Flatten when no key contains delim or ends in [n],
+// since Flatten does not escape them: {"a.b": 1} and {"a": {"b": 1}} both flatten to "a.b", which Unflatten reads as
+// the latter.  Use FlattenWith and UnflattenWith with Escape for keys that may.
+func (o Object) Unflatten(delim string) (Object, error) {
+	return o.UnflattenWith(FlattenOptions{Delim: delim})
+}
+
+// UnflattenWith rebuilds the object FlattenWith flattened with the same options, using only Delim, Indexes and Escape.
+// Without Escape it is ambiguous in the same way as Unflatten.
+func (o Object) UnflattenWith(options FlattenOptions) (Object, error) {
+	v, err := unflatten(o, options, true)
+	if err != nil {
+		return nil, err
+	}
+	return v.(Object), nil
+}
+
+// UnflattenMap rebuilds nested maps from keys joined by delim, as Unflatten does, and with the same ambiguity for keys
+// that contain delim or end in [n].  Nested maps are map[string]any rather than Object.
+func UnflattenMap(m map[string]any, delim string) (map[string]any, error) {
+	v, err := unflatten(m, FlattenOptions{Delim: delim}, false)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+// flatNode is a value or container being rebuilt by unflatten, along with the first flat key that reached it.
+type flatNode struct {
+	key   string
+	leaf  bool
+	value any
+	keys  map[string]*flatNode
+	items map[int]*flatNode
+}
+
+func unflatten(m map[string]any, options FlattenOptions, objects bool) (any, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &flatNode{keys: map[string]*flatNode{}}
+	for _, k := range keys {
+		parts, err := options.splitKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", err, k)
+		}
+		node := root
+		for i, part := range parts {
+			if part.kind != partKey && (part.kind != partIndex || part.index < 0 || i == 0) {
+				return nil, fmt.Errorf("%w: %q", ErrPath, k)
+			}
+			parent := node
+			if node = node.child(part, k); node == nil {
+				return nil, fmt.Errorf("%w: %q and %q", ErrFlattenConflict, parent.key, k)
+			}
+		}
+		if node.leaf || node.keys != nil || node.items != nil {
+			return nil, fmt.Errorf("%w: %q and %q", ErrFlattenConflict, node.key, k)
+		}
+		node.leaf, node.value, node.key = true, m[k], k
+	}
+	return root.build(objects), nil
+}
+
+// child returns the node below n for part, creating it for key if missing, or nil if n is already a value or the
+// other kind of container.
+func (n *flatNode) child(part pathPart, key string) *flatNode {
+	if n.leaf {
+		return nil
+	}
+	if part.kind == partKey {
+		if n.items != nil {
+			return nil
+		}
+		if n.keys == nil {
+			n.keys = map[string]*flatNode{}
+		}
+		if n.keys[part.key] == nil {
+			n.keys[part.key] = &flatNode{key: key}
+		}
+		return n.keys[part.key]
+	}
+	if n.keys != nil {
+		return nil
+	}
+	if n.items == nil {
+		n.items = map[int]*flatNode{}
+	}
+	if n.items[part.index] == nil {
+		n.items[part.index] = &flatNode{key: key}
+	}
+	return n.items[part.index]
+}
+
+// build returns the value n stands for, with Objects or map[string]any for its maps.
+func (n *flatNode) build(objects bool) any {
+	switch {
+	case n.leaf:
+		return n.value
+	case n.items != nil:
+		size := 0
+		for i := range n.items {
+			if i >= size {
+				size = i + 1
+			}
+		}
+		ret := make([]any, size)
+		for i, child := range n.items {
+			ret[i] = child.build(objects)
+		}
+		return ret
+	default:
+		ret := make(map[string]any, len(n.keys))
+		for k, child := range n.keys {
+			ret[k] = child.build(objects)
+		}
+		if objects {
+			return Object(ret)
+		}
+		return ret
+	}
+}
+
+// splitKey parses a flat key written by FlattenWith with options.
+func (options FlattenOptions) splitKey(key string) ([]pathPart, error) {
+	delim := options.delim()
+	if options.Escape {
+		if options.Indexes == IndexDotted {
+			key = dottedIndexes(key, delim)
+		}
+		return splitPathDelim(key, delim)
+	}
+	var parts []pathPart
+	for i, seg := range strings.Split(key, delim) {
+		if options.Indexes == IndexDotted && i > 0 && isDigits(seg) {
+			n, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, ErrPath
+			}
+			parts = append(parts, pathPart{kind: partIndex, index: n})
+			continue
+		}
+		var indexes []pathPart
+		for options.Indexes == IndexBrackets && strings.HasSuffix(seg, "]") {
+			open := strings.LastIndexByte(seg, '[')
+			if open < 0 || !isDigits(seg[open+1:len(seg)-1]) {
+				break
+			}
+			n, err := strconv.Atoi(seg[open+1 : len(seg)-1])
+			if err != nil {
+				return nil, ErrPath
+			}
+			indexes = append([]pathPart{{kind: partIndex, index: n}}, indexes...)
+			seg = seg[:open]
+		}
+		parts = append(parts, pathPart{kind: partKey, key: seg})
+		parts = append(parts, indexes...)
+	}
+	return parts, nil
+}
+
+// dottedIndexes rewrites the keys of a flat key written with IndexDotted that are only digits, other than the first,
+// as [n] indexes.  Escaped keys are left alone.
+func dottedIndexes(key string, delim string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); {
+		if i > 0 && strings.HasPrefix(key[i:], delim) {
+			start := i + len(delim)
+			end := start
+			for end < len(key) && key[end] >= '0' && key[end] <= '9' {
+				end++
+			}
+			if end > start && (end == len(key) || key[end] == '[' || strings.HasPrefix(key[end:], delim)) {
+				sb.WriteString("[" + key[start:end] + "]")
+				i = end
+				continue
+			}
+			sb.WriteString(delim)
+			i = start
+			continue
+		}
+		if key[i] == '\\' && i+1 < len(key) {
+			sb.WriteString(key[i : i+2])
+			i += 2
+			continue
+		}
+		sb.WriteByte(key[i])
+		i++
+	}
+	return sb.String()
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
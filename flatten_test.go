@@ -0,0 +1,134 @@
+package tox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnflatten(t *testing.T) {
+	o := Object{
+		"a":      "abc",
+		"c":      Object{"d": 123, "e": Object{"f": 456}},
+		"g":      []any{"h", Object{"i": []any{1, 2}}, []any{true}},
+		"x.y":    Object{"[z]": nil},
+		"digits": Object{"0": "zero"},
+	}
+	for _, options := range []FlattenOptions{
+		{Escape: true},
+		{Delim: "/", Escape: true},
+		{Indexes: IndexDotted, Escape: true},
+		{Delim: "__", Indexes: IndexDotted, Escape: true},
+		{MaxDepth: 2, Escape: true},
+	} {
+		flat := o.FlattenWith(options)
+		back, err := flat.UnflattenWith(options)
+		assert.NoError(t, err, options)
+		assert.Equal(t, o, back, options)
+	}
+
+	plain := Object{"a": "abc", "c": Object{"d": 123}, "g": []any{"h", Object{"i": []any{1, 2}}}}
+	for _, options := range []FlattenOptions{{}, {Delim: "/"}, {Indexes: IndexDotted}} {
+		back, err := plain.FlattenWith(options).UnflattenWith(options)
+		assert.NoError(t, err, options)
+		assert.Equal(t, plain, back, options)
+	}
+	assert.Equal(t, Object{"x.y.[z]": nil, "digits.0": "zero"}, Object{"x.y": Object{"[z]": nil}, "digits": Object{"0": "zero"}}.Flatten("."))
+
+	assert.Equal(t, Object{"a": 1, "b.0": 2, "b.1.c": 3, `d.\0`: 4},
+		Object{"a": 1, "b": []any{2, Object{"c": 3}}, "d": Object{"0": 4}}.FlattenWith(FlattenOptions{Indexes: IndexDotted, Escape: true}))
+	assert.Equal(t, Object{"a": 1, "b.0": 2, "d.0": 4},
+		Object{"a": 1, "b": []any{2}, "d": Object{"0": 4}}.FlattenWith(FlattenOptions{Indexes: IndexDotted}))
+	assert.Equal(t, Object{"a.b": Object{"c": 1}, "d[0]": []any{2}},
+		Object{"a": Object{"b": Object{"c": 1}}, "d": []any{[]any{2}}}.FlattenWith(FlattenOptions{MaxDepth: 2}))
+
+	empty := Object{"a": Object{}, "b": []any{}, "c": Object{"d": []string{}}}
+	assert.Equal(t, Object{}, empty.FlattenWith(FlattenOptions{}))
+	flat := empty.Flatten(".")
+	assert.Equal(t, Object{"a": Object{}, "b": []any{}, "c.d": []string{}}, flat)
+	back, err := flat.Unflatten(".")
+	assert.NoError(t, err)
+	assert.Equal(t, empty, back)
+
+	sparse, err := Object{"a[2]": 1, "a[0].b": 2, "c[1][1]": 3}.Unflatten(".")
+	assert.NoError(t, err)
+	assert.Equal(t, Object{"a": []any{Object{"b": 2}, nil, 1}, "c": []any{nil, []any{nil, 3}}}, sparse)
+
+	m, err := UnflattenMap(map[string]any{"a_b": 1, "a_c_d": 2}, "_")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": 1, "c": map[string]any{"d": 2}}}, m)
+
+	for _, bad := range []Object{
+		{"a": 1, "a.b": 2},
+		{"a.b": 1, "a[0]": 2},
+		{"a[0]": 1, "a[0].b": 2},
+		{"a": Object{}, "a.b": 1},
+	} {
+		_, err = bad.Unflatten(".")
+		assert.ErrorIs(t, err, ErrFlattenConflict, bad)
+	}
+	for _, bad := range []Object{{"a[]": 1}, {"a[-1]": 1}, {"[0]": 1}, {`a["b`: 1}} {
+		_, err = bad.UnflattenWith(FlattenOptions{Escape: true})
+		assert.ErrorIs(t, err, ErrPath, bad)
+	}
+	literal, err := Object{"a[]": 1, "b[-1]": 2, `c["d`: 3}.Unflatten(".")
+	assert.NoError(t, err)
+	assert.Equal(t, Object{"a[]": 1, "b[-1]": 2, `c["d`: 3}, literal)
+
+	// without Escape, keys holding the delim or an index read back as paths
+	ambiguous := Object{"a.b": 1, "c[0]": 2}
+	assert.Equal(t, Object{"a.b": 1, "c[0]": 2}, ambiguous.Flatten("."))
+	back, err = ambiguous.Flatten(".").Unflatten(".")
+	assert.NoError(t, err)
+	assert.Equal(t, Object{"a": Object{"b": 1}, "c": []any{2}}, back)
+	back, err = ambiguous.FlattenWith(FlattenOptions{Escape: true}).UnflattenWith(FlattenOptions{Escape: true})
+	assert.NoError(t, err)
+	assert.Equal(t, ambiguous, back)
+}
+
+func TestFlattenEngine(t *testing.T) {
+	m := map[string]any{
+		"a": Object{"b": []Object{{"c": 1}}},
+		"d": []map[string]any{{"e": 2}},
+		"f": map[string]string{"g": "h"},
+		"i": [2]int{3, 4},
+	}
+	want := map[string]any{"a.b[0].c": 1, "d[0].e": 2, "f.g": "h", "i[0]": 3, "i[1]": 4}
+	assert.Equal(t, want, FlattenMap(m, "."))
+	assert.Equal(t, Object(want), Object(m).Flatten("."))
+
+	assert.Equal(t, map[string]any{"a": []any{}, "b": map[string]any{}, "c.d": []string{}},
+		FlattenMap(map[string]any{"a": []any{}, "b": map[string]any{}, "c": map[string]any{"d": []string{}}}, "."))
+
+	flat := map[string]any{"a": 1}
+	out := FlattenMap(flat, ".")
+	out["b"] = 2
+	assert.Equal(t, map[string]any{"a": 1}, flat)
+
+	o := Object{"Name": "x", "Tags": []string{"a", "b"}, "Raw": []byte{1, 2}, "Sub": Object{"Id": 1}}
+	assert.Equal(t, Object{"name": "x", "tags": []string{"a", "b"}, "raw": []byte{1, 2}, "sub/id": 1},
+		o.FlattenWith(FlattenOptions{
+			Delim: "/",
+			Leaf: func(path string, value any) bool {
+				_, bytes := value.([]byte)
+				return bytes || path == "tags"
+			},
+			Key: strings.ToLower,
+		}))
+
+	var paths []string
+	var values []any
+	o.WalkLeaves(func(path string, v any) {
+		paths = append(paths, path)
+		values = append(values, v)
+	})
+	assert.Equal(t, []string{"Name", "Raw[0]", "Raw[1]", "Sub.Id", "Tags[0]", "Tags[1]"}, paths)
+	assert.Equal(t, []any{"x", byte(1), byte(2), 1, "a", "b"}, values)
+
+	paths = nil
+	o.WalkLeavesWith(FlattenOptions{Indexes: IndexDotted, MaxDepth: 1}, func(path string, v any) {
+		paths = append(paths, path)
+	})
+	assert.Equal(t, []string{"Name", "Raw", "Sub", "Tags"}, paths)
+}
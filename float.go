@@ -8,44 +8,65 @@ import (
 
 // ToFloat64 converts any data type to a float64, if the conversion fails, it returns NaN.
 func ToFloat64(v interface{}) float64 {
+	f, err := ToFloat64E(v)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// ToFloat64E converts any data type to a float64, returning an error if the value cannot be converted.
+func ToFloat64E(v interface{}) (float64, error) {
 	switch v := v.(type) {
 	case int:
-		return float64(v)
+		return float64(v), nil
 	case int8:
-		return float64(v)
+		return float64(v), nil
 	case int16:
-		return float64(v)
+		return float64(v), nil
 	case int32:
-		return float64(v)
+		return float64(v), nil
 	case int64:
-		return float64(v)
+		return float64(v), nil
 	case uint:
-		return float64(v)
+		return float64(v), nil
 	case uint8:
-		return float64(v)
+		return float64(v), nil
 	case uint16:
-		return float64(v)
+		return float64(v), nil
 	case uint32:
-		return float64(v)
+		return float64(v), nil
 	case uint64:
-		return float64(v)
+		return float64(v), nil
 	case float32:
-		return float64(v)
+		return float64(v), nil
 	case float64:
-		return v
+		return v, nil
 	case string:
-		i, err := strconv.ParseFloat(v, 64)
+		f, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			return math.NaN()
+			return f, strconvError(v, "float64", err)
 		}
-		return i
+		return f, nil
 	case bool:
 		if v {
-			return float64(1)
+			return float64(1), nil
 		}
-		return float64(0)
+		return float64(0), nil
 	default:
-		return math.NaN()
+		if cv, found, err := convertRegistered(v, float64Type, stringType); found {
+			if err != nil {
+				return math.NaN(), err
+			}
+			return ToFloat64E(cv)
+		}
+		if bv, ok := namedBasic(v); ok {
+			return ToFloat64E(bv)
+		}
+		if s, ok := textOf(v); ok {
+			return ToFloat64E(s)
+		}
+		return math.NaN(), newConversionError(v, "float64", ErrUnsupportedType)
 	}
 }
 
@@ -3,7 +3,6 @@ module github.com/qwerty-iot/tox
 go 1.20
 
 require (
-	dario.cat/mergo v1.0.2
 	github.com/davecgh/go-spew v1.1.1
 	github.com/goccy/go-json v0.10.5
 	github.com/stretchr/testify v1.11.1
@@ -2,47 +2,18 @@ package tox
 
 import (
 	"reflect"
-	"strconv"
 )
 
-// ToInt converts any data type to a bool, if the conversion fails, it returns 0.
+// ToInt converts any data type to an int, if the conversion fails, it returns 0.  Out of range values wrap, use
+// ToIntChecked to detect or clamp them instead.
 func ToInt(v interface{}) int {
-	switch v := v.(type) {
-	case int:
-		return v
-	case int8:
-		return int(v)
-	case int16:
-		return int(v)
-	case int32:
-		return int(v)
-	case int64:
-		return int(v)
-	case uint:
-		return int(v)
-	case uint8:
-		return int(v)
-	case uint16:
-		return int(v)
-	case uint32:
-		return int(v)
-	case uint64:
-		return int(v)
-	case float32:
-		return int(v)
-	case float64:
-		return int(v)
-	case string:
-		i, _ := strconv.Atoi(v)
-		return i
-	case bool:
-		if v {
-			return 1
-		}
-		return 0
-	default:
-		return 0
-	}
+	i, _ := ToIntChecked(v, OverflowWrap)
+	return i
+}
+
+// ToIntE converts any data type to an int, returning an error if the value cannot be converted or does not fit.
+func ToIntE(v interface{}) (int, error) {
+	return ToIntChecked(v, OverflowError)
 }
 
 func ToIntPtr(v interface{}) *int {
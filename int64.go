@@ -1,45 +1,14 @@
 package tox
 
-import "strconv"
-
-// ToInt converts any data type to a int64, if the conversion fails, it returns 0.
+// ToInt64 converts any data type to an int64, if the conversion fails, it returns 0.
 func ToInt64(v interface{}) int64 {
-	switch v := v.(type) {
-	case int:
-		return int64(v)
-	case int8:
-		return int64(v)
-	case int16:
-		return int64(v)
-	case int32:
-		return int64(v)
-	case int64:
-		return v
-	case uint:
-		return int64(v)
-	case uint8:
-		return int64(v)
-	case uint16:
-		return int64(v)
-	case uint32:
-		return int64(v)
-	case uint64:
-		return int64(v)
-	case float32:
-		return int64(v)
-	case float64:
-		return int64(v)
-	case string:
-		i, _ := strconv.ParseInt(v, 10, 64)
-		return i
-	case bool:
-		if v {
-			return 1
-		}
-		return 0
-	default:
-		return 0
-	}
+	i, _ := ToInt64Checked(v, OverflowWrap)
+	return i
+}
+
+// ToInt64E converts any data type to an int64, returning an error if the value cannot be converted or does not fit.
+func ToInt64E(v interface{}) (int64, error) {
+	return ToInt64Checked(v, OverflowError)
 }
 
 func ToInt64Ptr(v interface{}) *int64 {
@@ -0,0 +1,390 @@
+package tox
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OverflowPolicy selects how the checked integer conversions treat values that do not fit in the target type.
+type OverflowPolicy int
+
+const (
+	// OverflowWrap truncates fractions and wraps out of range values like a Go conversion, this is what ToInt and
+	// friends have always done.
+	OverflowWrap OverflowPolicy = iota
+	// OverflowSaturate truncates fractions and clamps out of range values to the minimum or maximum of the target
+	// type.  NaN becomes 0.
+	OverflowSaturate
+	// OverflowError returns ErrOverflow, ErrTruncated or ErrNaN instead of converting a value that does not fit.
+	OverflowError
+)
+
+// ToIntChecked converts any data type to an int, handling fractions, NaN and out of range values as policy says.
+func ToIntChecked(v any, policy OverflowPolicy) (int, error) {
+	min, max := platformIntLimits()
+	i, err := checkedSigned(v, "int", min, max, policy)
+	return int(i), err
+}
+
+// ToInt8Checked converts any data type to an int8, handling fractions, NaN and out of range values as policy says.
+func ToInt8Checked(v any, policy OverflowPolicy) (int8, error) {
+	i, err := checkedSigned(v, "int8", math.MinInt8, math.MaxInt8, policy)
+	return int8(i), err
+}
+
+// ToInt16Checked converts any data type to an int16, handling fractions, NaN and out of range values as policy says.
+func ToInt16Checked(v any, policy OverflowPolicy) (int16, error) {
+	i, err := checkedSigned(v, "int16", math.MinInt16, math.MaxInt16, policy)
+	return int16(i), err
+}
+
+// ToInt32Checked converts any data type to an int32, handling fractions, NaN and out of range values as policy says.
+func ToInt32Checked(v any, policy OverflowPolicy) (int32, error) {
+	i, err := checkedSigned(v, "int32", math.MinInt32, math.MaxInt32, policy)
+	return int32(i), err
+}
+
+// ToInt64Checked converts any data type to an int64, handling fractions, NaN and out of range values as policy says.
+func ToInt64Checked(v any, policy OverflowPolicy) (int64, error) {
+	return checkedSigned(v, "int64", math.MinInt64, math.MaxInt64, policy)
+}
+
+// ToUintChecked converts any data type to a uint, handling fractions, NaN and out of range values as policy says.
+func ToUintChecked(v any, policy OverflowPolicy) (uint, error) {
+	_, max := platformIntLimits()
+	u, err := checkedUnsigned(v, "uint", uint64(max)<<1|1, policy)
+	return uint(u), err
+}
+
+// ToUint8Checked converts any data type to a uint8, handling fractions, NaN and out of range values as policy says.
+func ToUint8Checked(v any, policy OverflowPolicy) (uint8, error) {
+	u, err := checkedUnsigned(v, "uint8", math.MaxUint8, policy)
+	return uint8(u), err
+}
+
+// ToUint16Checked converts any data type to a uint16, handling fractions, NaN and out of range values as policy says.
+func ToUint16Checked(v any, policy OverflowPolicy) (uint16, error) {
+	u, err := checkedUnsigned(v, "uint16", math.MaxUint16, policy)
+	return uint16(u), err
+}
+
+// ToUint32Checked converts any data type to a uint32, handling fractions, NaN and out of range values as policy says.
+func ToUint32Checked(v any, policy OverflowPolicy) (uint32, error) {
+	u, err := checkedUnsigned(v, "uint32", math.MaxUint32, policy)
+	return uint32(u), err
+}
+
+// ToUint64Checked converts any data type to a uint64, handling fractions, NaN and out of range values as policy says.
+func ToUint64Checked(v any, policy OverflowPolicy) (uint64, error) {
+	return checkedUnsigned(v, "uint64", math.MaxUint64, policy)
+}
+
+// ToInt8 converts any data type to an int8, out of range values wrap.  If the conversion fails, it returns 0.
+func ToInt8(v any) int8 {
+	i, _ := ToInt8Checked(v, OverflowWrap)
+	return i
+}
+
+// ToInt8E converts any data type to an int8, returning an error if the value cannot be converted or does not fit.
+func ToInt8E(v any) (int8, error) {
+	return ToInt8Checked(v, OverflowError)
+}
+
+// ToInt16 converts any data type to an int16, out of range values wrap.  If the conversion fails, it returns 0.
+func ToInt16(v any) int16 {
+	i, _ := ToInt16Checked(v, OverflowWrap)
+	return i
+}
+
+// ToInt16E converts any data type to an int16, returning an error if the value cannot be converted or does not fit.
+func ToInt16E(v any) (int16, error) {
+	return ToInt16Checked(v, OverflowError)
+}
+
+// ToInt32 converts any data type to an int32, out of range values wrap.  If the conversion fails, it returns 0.
+func ToInt32(v any) int32 {
+	i, _ := ToInt32Checked(v, OverflowWrap)
+	return i
+}
+
+// ToInt32E converts any data type to an int32, returning an error if the value cannot be converted or does not fit.
+func ToInt32E(v any) (int32, error) {
+	return ToInt32Checked(v, OverflowError)
+}
+
+// ToUint converts any data type to a uint, out of range values wrap.  If the conversion fails, it returns 0.
+func ToUint(v any) uint {
+	u, _ := ToUintChecked(v, OverflowWrap)
+	return u
+}
+
+// ToUintE converts any data type to a uint, returning an error if the value cannot be converted or does not fit.
+func ToUintE(v any) (uint, error) {
+	return ToUintChecked(v, OverflowError)
+}
+
+// ToUint8 converts any data type to a uint8, out of range values wrap.  If the conversion fails, it returns 0.
+func ToUint8(v any) uint8 {
+	u, _ := ToUint8Checked(v, OverflowWrap)
+	return u
+}
+
+// ToUint8E converts any data type to a uint8, returning an error if the value cannot be converted or does not fit.
+func ToUint8E(v any) (uint8, error) {
+	return ToUint8Checked(v, OverflowError)
+}
+
+// ToUint16 converts any data type to a uint16, out of range values wrap.  If the conversion fails, it returns 0.
+func ToUint16(v any) uint16 {
+	u, _ := ToUint16Checked(v, OverflowWrap)
+	return u
+}
+
+// ToUint16E converts any data type to a uint16, returning an error if the value cannot be converted or does not fit.
+func ToUint16E(v any) (uint16, error) {
+	return ToUint16Checked(v, OverflowError)
+}
+
+// ToUint32 converts any data type to a uint32, out of range values wrap.  If the conversion fails, it returns 0.
+func ToUint32(v any) uint32 {
+	u, _ := ToUint32Checked(v, OverflowWrap)
+	return u
+}
+
+// ToUint32E converts any data type to a uint32, returning an error if the value cannot be converted or does not fit.
+func ToUint32E(v any) (uint32, error) {
+	return ToUint32Checked(v, OverflowError)
+}
+
+// ToUint64 converts any data type to a uint64, out of range values wrap.  If the conversion fails, it returns 0.
+func ToUint64(v any) uint64 {
+	u, _ := ToUint64Checked(v, OverflowWrap)
+	return u
+}
+
+// ToUint64E converts any data type to a uint64, returning an error if the value cannot be converted or does not fit.
+func ToUint64E(v any) (uint64, error) {
+	return ToUint64Checked(v, OverflowError)
+}
+
+// integerSource reduces a value to a signed, unsigned or floating point number so the range checks only have to deal
+// with three cases.  Strings are parsed as base 10 integers, and parsed records that they were.
+type integerSource struct {
+	kind   byte // 'i', 'u' or 'f'
+	i      int64
+	u      uint64
+	f      float64
+	err    error
+	parsed bool
+}
+
+func toIntegerSource(v any, to string, policy OverflowPolicy) integerSource {
+	switch v := v.(type) {
+	case int:
+		return integerSource{kind: 'i', i: int64(v)}
+	case int8:
+		return integerSource{kind: 'i', i: int64(v)}
+	case int16:
+		return integerSource{kind: 'i', i: int64(v)}
+	case int32:
+		return integerSource{kind: 'i', i: int64(v)}
+	case int64:
+		return integerSource{kind: 'i', i: v}
+	case uint:
+		return integerSource{kind: 'u', u: uint64(v)}
+	case uint8:
+		return integerSource{kind: 'u', u: uint64(v)}
+	case uint16:
+		return integerSource{kind: 'u', u: uint64(v)}
+	case uint32:
+		return integerSource{kind: 'u', u: uint64(v)}
+	case uint64:
+		return integerSource{kind: 'u', u: v}
+	case float32:
+		return integerSource{kind: 'f', f: float64(v)}
+	case float64:
+		return integerSource{kind: 'f', f: v}
+	case bool:
+		if v {
+			return integerSource{kind: 'i', i: 1}
+		}
+		return integerSource{kind: 'i'}
+	case string:
+		if !strings.HasPrefix(v, "-") {
+			u, err := strconv.ParseUint(strings.TrimPrefix(v, "+"), 10, 64)
+			if err == nil || (policy == OverflowSaturate && errors.Is(err, strconv.ErrRange)) {
+				return integerSource{kind: 'u', u: u, parsed: true}
+			}
+		}
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			if policy != OverflowError && errors.Is(err, strconv.ErrRange) {
+				// strconv clamps out of range strings, which is what ToInt has always returned for them
+				return integerSource{kind: 'i', i: i, parsed: true}
+			}
+			return integerSource{err: strconvError(v, to, err)}
+		}
+		return integerSource{kind: 'i', i: i, parsed: true}
+	default:
+		if cv, found, err := convertRegistered(v, int64Type, float64Type, stringType); found {
+			if err != nil {
+				return integerSource{err: err}
+			}
+			return toIntegerSource(cv, to, policy)
+		}
+		if bv, ok := namedBasic(v); ok {
+			return toIntegerSource(bv, to, policy)
+		}
+		if s, ok := textOf(v); ok {
+			return toIntegerSource(s, to, policy)
+		}
+		return integerSource{err: newConversionError(v, to, ErrUnsupportedType)}
+	}
+}
+
+func checkedSigned(v any, to string, min int64, max int64, policy OverflowPolicy) (int64, error) {
+	src := toIntegerSource(v, to, policy)
+	if src.err != nil {
+		return 0, src.err
+	}
+	switch src.kind {
+	case 'u':
+		if policy == OverflowWrap && src.parsed && src.u > math.MaxInt64 {
+			// strings beyond int64 clamp to it as strconv.ParseInt does, which is what ToInt has always returned
+			src.u = math.MaxInt64
+		}
+		if src.u > uint64(max) {
+			switch policy {
+			case OverflowSaturate:
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+			return wrapSigned(int64(src.u), max), nil
+		}
+		return int64(src.u), nil
+	case 'f':
+		f := src.f
+		if math.IsNaN(f) {
+			switch policy {
+			case OverflowSaturate:
+				return 0, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrNaN)
+			}
+			return wrapSigned(int64(f), max), nil
+		}
+		if f < float64(min) || f >= -float64(min) {
+			switch policy {
+			case OverflowSaturate:
+				if f < 0 {
+					return min, nil
+				}
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+			return wrapSigned(int64(f), max), nil
+		}
+		if policy == OverflowError && f != math.Trunc(f) {
+			return 0, newConversionError(v, to, ErrTruncated)
+		}
+		return int64(f), nil
+	default:
+		if src.i < min || src.i > max {
+			switch policy {
+			case OverflowSaturate:
+				if src.i < min {
+					return min, nil
+				}
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+			return wrapSigned(src.i, max), nil
+		}
+		return src.i, nil
+	}
+}
+
+func checkedUnsigned(v any, to string, max uint64, policy OverflowPolicy) (uint64, error) {
+	src := toIntegerSource(v, to, policy)
+	if src.err != nil {
+		return 0, src.err
+	}
+	switch src.kind {
+	case 'i':
+		if src.i < 0 {
+			switch policy {
+			case OverflowSaturate:
+				return 0, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+			return uint64(src.i) & max, nil
+		}
+		if uint64(src.i) > max {
+			switch policy {
+			case OverflowSaturate:
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+		}
+		return uint64(src.i) & max, nil
+	case 'f':
+		f := src.f
+		if math.IsNaN(f) {
+			switch policy {
+			case OverflowSaturate:
+				return 0, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrNaN)
+			}
+			return uint64(int64(f)) & max, nil
+		}
+		if f < 0 || f >= float64(max)+1 {
+			switch policy {
+			case OverflowSaturate:
+				if f < 0 {
+					return 0, nil
+				}
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+		}
+		if policy == OverflowError && f != math.Trunc(f) {
+			return 0, newConversionError(v, to, ErrTruncated)
+		}
+		if f >= math.MaxInt64 {
+			return uint64(f) & max, nil
+		}
+		return uint64(int64(f)) & max, nil
+	default:
+		if src.u > max {
+			switch policy {
+			case OverflowSaturate:
+				return max, nil
+			case OverflowError:
+				return 0, newConversionError(v, to, ErrOverflow)
+			}
+		}
+		return src.u & max, nil
+	}
+}
+
+// wrapSigned truncates i to the width of a signed integer whose maximum is max, the same as a Go conversion would.
+func wrapSigned(i int64, max int64) int64 {
+	if max == math.MaxInt64 {
+		return i
+	}
+	mask := uint64(max)<<1 | 1
+	u := uint64(i) & mask
+	if u > uint64(max) {
+		return int64(u) - int64(mask) - 1
+	}
+	return int64(u)
+}
@@ -0,0 +1,58 @@
+package tox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckedIntegers(t *testing.T) {
+	// wrap keeps the historic behavior
+	assert.Equal(t, byte(44), ToByte(300))
+	assert.Equal(t, int8(-56), ToInt8(200))
+	assert.Equal(t, -1, ToInt(uint64(math.MaxUint64)))
+	assert.Equal(t, uint32(math.MaxUint32), ToUint32(-1))
+	assert.Equal(t, 1, ToInt(1.9))
+	// strings beyond int64 clamp, as strconv.ParseInt did
+	assert.Equal(t, int64(math.MaxInt64), ToInt64("18446744073709551615"))
+	assert.Equal(t, math.MaxInt, ToInt("18446744073709551615"))
+	assert.Equal(t, int64(math.MinInt64), ToInt64("-99999999999999999999"))
+	assert.Equal(t, uint64(math.MaxUint64), ToUint64("18446744073709551615"))
+
+	// saturate clamps to the target range
+	b, err := ToUint8Checked(300, OverflowSaturate)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(255), b)
+	i8, _ := ToInt8Checked(-1000, OverflowSaturate)
+	assert.Equal(t, int8(math.MinInt8), i8)
+	i, _ := ToIntChecked(1e30, OverflowSaturate)
+	assert.Equal(t, math.MaxInt, i)
+	u, _ := ToUint64Checked(-5, OverflowSaturate)
+	assert.Equal(t, uint64(0), u)
+	u, _ = ToUint64Checked("99999999999999999999999", OverflowSaturate)
+	assert.Equal(t, uint64(math.MaxUint64), u)
+	i, _ = ToIntChecked(math.NaN(), OverflowSaturate)
+	assert.Equal(t, 0, i)
+
+	// error reports what went wrong
+	_, err = ToByteE(300)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = ToIntE(uint64(math.MaxUint64))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = ToInt32E(1.5)
+	assert.ErrorIs(t, err, ErrTruncated)
+	_, err = ToInt64E(math.NaN())
+	assert.ErrorIs(t, err, ErrNaN)
+	_, err = ToUintE(math.Inf(1))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = ToUint16E("x")
+	assert.ErrorIs(t, err, ErrSyntax)
+
+	u64, err := ToUint64E("18446744073709551615")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), u64)
+	i32, err := ToInt32E(float64(-2147483648))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(math.MinInt32), i32)
+}
@@ -3,7 +3,7 @@ package tox
 import (
 	"reflect"
 	"strconv"
-	"time"
+	"strings"
 	"unicode"
 )
 
@@ -81,15 +81,19 @@ func IsPrimitive(i any) bool {
 	}
 }
 
+// IsTime returns true for time.Time values and strings that match one of the default time layouts, see
+// SetDefaultTimeOptions.  Numeric strings are not considered times.
 func IsTime(i any) bool {
-	if reflect.TypeOf(i).PkgPath() == "time" && reflect.TypeOf(i).Name() == "Time" {
+	if i == nil {
+		return false
+	} else if reflect.TypeOf(i).PkgPath() == "time" && reflect.TypeOf(i).Name() == "Time" {
 		return true
 	} else if reflect.TypeOf(i).Kind() == reflect.Ptr && reflect.TypeOf(i).Elem().PkgPath() == "time" && reflect.TypeOf(i).Elem().Name() == "Time" {
 		return true
 	} else if reflect.TypeOf(i).Kind() == reflect.Interface && reflect.TypeOf(i).Elem().PkgPath() == "time" && reflect.TypeOf(i).Elem().Name() == "Time" {
 		return true
 	} else if IsString(i) {
-		t, err := time.Parse(time.RFC3339Nano, ToString(i))
+		t, err := parseTimeLayouts(strings.TrimSpace(ToString(i)), timeOptions(nil))
 		return err == nil && !t.IsZero()
 	}
 	return false
@@ -0,0 +1,772 @@
+package tox
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONPath is a compiled JSONPath expression.  It supports the RFC 9535 syntax for the root ($), child (.name,
+// ['name']), wildcard (* and [*]), descendant (..), index ([0], [-1]), slice ([start:end:step]) and union ([0,'a'])
+// selectors, and filters such as [?@.temp > 30] or [?(@.temp > 30 && @.on)] with the length, count, match, search
+// and value functions.  It evaluates directly against Object, map[string]any and slices without a JSON round trip.
+type JSONPath struct {
+	expr     string
+	segments []jpSegment
+}
+
+type jpSelectorKind int
+
+const (
+	jpName jpSelectorKind = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSelector struct {
+	kind   jpSelectorKind
+	name   string
+	index  int
+	start  *int
+	end    *int
+	step   *int
+	filter *jpExpr
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+// jpExpr is a node of a filter expression.  op is "||", "&&", "!" or a comparison for logical nodes, "test" for an
+// existence test of left, and "literal", "query" or "func" for values.
+type jpExpr struct {
+	op       string
+	left     *jpExpr
+	right    *jpExpr
+	args     []*jpExpr
+	value    any
+	name     string
+	relative bool
+	segments []jpSegment
+}
+
+type jpNode struct {
+	path  []pathPart
+	value any
+}
+
+// CompileJSONPath parses expr, returning an error wrapping ErrPath when it is not a valid JSONPath.
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	p := &jpParser{s: expr}
+	if !p.accept("$") {
+		return nil, p.errorf("expected $")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.i != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.i:])
+	}
+	return &JSONPath{expr: expr, segments: segments}, nil
+}
+
+// EvalJSONPath compiles expr and evaluates it against data.
+func EvalJSONPath(expr string, data any) ([]QueryResult, error) {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(data), nil
+}
+
+// JSONPath evaluates a JSONPath expression against the object.
+func (o Object) JSONPath(expr string) ([]QueryResult, error) {
+	return EvalJSONPath(expr, o)
+}
+
+func (p *JSONPath) String() string {
+	return p.expr
+}
+
+// Eval returns the values matched by the expression, each with its normalized path such as $['devices'][0].
+func (p *JSONPath) Eval(data any) []QueryResult {
+	nodes := evalSegments(p.segments, data, []jpNode{{value: data}})
+	if len(nodes) == 0 {
+		return nil
+	}
+	results := make([]QueryResult, len(nodes))
+	for i, n := range nodes {
+		results[i] = QueryResult{Path: normalizedPath(n.path), Value: n.value}
+	}
+	return results
+}
+
+func evalSegments(segments []jpSegment, root any, nodes []jpNode) []jpNode {
+	for _, seg := range segments {
+		var out []jpNode
+		for _, n := range nodes {
+			if seg.descendant {
+				descend(n, func(d jpNode) {
+					out = seg.apply(d, root, out)
+				})
+			} else {
+				out = seg.apply(n, root, out)
+			}
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+// descend calls fn for n and then for each of its descendants, depth first.
+func descend(n jpNode, fn func(jpNode)) {
+	fn(n)
+	eachChild(n.value, func(child any, part pathPart) {
+		descend(jpNode{path: appendPart(n.path, part), value: child}, fn)
+	})
+}
+
+func (seg jpSegment) apply(n jpNode, root any, out []jpNode) []jpNode {
+	for _, sel := range seg.selectors {
+		out = sel.apply(n, root, out)
+	}
+	return out
+}
+
+func (sel jpSelector) apply(n jpNode, root any, out []jpNode) []jpNode {
+	child := func(v any, part pathPart) {
+		out = append(out, jpNode{path: appendPart(n.path, part), value: v})
+	}
+	switch sel.kind {
+	case jpName:
+		if m, ok := mapOf(n.value); ok {
+			if v, found := m[sel.name]; found {
+				child(v, pathPart{kind: partKey, key: sel.name})
+			}
+		}
+	case jpWildcard:
+		eachChild(n.value, child)
+	case jpIndex:
+		rv := reflect.ValueOf(n.value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			if i := resolveIndex(sel.index, rv.Len()); i >= 0 && i < rv.Len() {
+				child(rv.Index(i).Interface(), pathPart{kind: partIndex, index: i})
+			}
+		}
+	case jpSlice:
+		rv := reflect.ValueOf(n.value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			for _, i := range sel.indexes(rv.Len()) {
+				child(rv.Index(i).Interface(), pathPart{kind: partIndex, index: i})
+			}
+		}
+	case jpFilter:
+		eachChild(n.value, func(v any, part pathPart) {
+			if sel.filter.test(root, v) {
+				child(v, part)
+			}
+		})
+	}
+	return out
+}
+
+// indexes returns the indexes selected by a slice of an array of length n, following RFC 9535 section 2.3.4.
+func (sel jpSelector) indexes(n int) []int {
+	step := 1
+	if sel.step != nil {
+		step = *sel.step
+	}
+	if step == 0 {
+		return nil
+	}
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		return resolveIndex(*p, n)
+	}
+	var ret []int
+	if step > 0 {
+		lower, upper := clampIndex(bound(sel.start, 0), n), clampIndex(bound(sel.end, n), n)
+		for i := lower; i < upper; i += step {
+			ret = append(ret, i)
+		}
+	} else {
+		upper, lower := clampIndex(bound(sel.start, n-1)+1, n)-1, clampIndex(bound(sel.end, -n-1)+1, n)-1
+		for i := upper; lower < i; i += step {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+func (e *jpExpr) test(root any, current any) bool {
+	switch e.op {
+	case "||":
+		return e.left.test(root, current) || e.right.test(root, current)
+	case "&&":
+		return e.left.test(root, current) && e.right.test(root, current)
+	case "!":
+		return !e.left.test(root, current)
+	case "test":
+		if e.left.op == "query" {
+			return len(e.left.nodes(root, current)) > 0
+		}
+		v, ok := e.left.eval(root, current)
+		return ok && v != false
+	}
+	a, aok := e.left.eval(root, current)
+	b, bok := e.right.eval(root, current)
+	switch e.op {
+	case "==":
+		return jpEqual(a, aok, b, bok)
+	case "!=":
+		return !jpEqual(a, aok, b, bok)
+	case "<":
+		return jpLess(a, aok, b, bok)
+	case ">":
+		return jpLess(b, bok, a, aok)
+	case "<=":
+		return jpLess(a, aok, b, bok) || jpEqual(a, aok, b, bok)
+	case ">=":
+		return jpLess(b, bok, a, aok) || jpEqual(a, aok, b, bok)
+	}
+	return false
+}
+
+// eval returns the value of a literal, singular query or function, and false when it has no value.
+func (e *jpExpr) eval(root any, current any) (any, bool) {
+	switch e.op {
+	case "literal":
+		return e.value, true
+	case "query":
+		if nodes := e.nodes(root, current); len(nodes) == 1 {
+			return nodes[0].value, true
+		}
+	case "func":
+		return e.call(root, current)
+	}
+	return nil, false
+}
+
+func (e *jpExpr) nodes(root any, current any) []jpNode {
+	start := root
+	if e.relative {
+		start = current
+	}
+	return evalSegments(e.segments, root, []jpNode{{value: start}})
+}
+
+func (e *jpExpr) call(root any, current any) (any, bool) {
+	switch e.name {
+	case "length":
+		v, ok := e.args[0].eval(root, current)
+		if !ok {
+			return nil, false
+		}
+		if s, ok := v.(string); ok {
+			return utf8.RuneCountInString(s), true
+		}
+		if m, ok := mapOf(v); ok {
+			return len(m), true
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			return rv.Len(), true
+		}
+	case "count":
+		return len(e.args[0].nodes(root, current)), true
+	case "value":
+		if nodes := e.args[0].nodes(root, current); len(nodes) == 1 {
+			return nodes[0].value, true
+		}
+	case "match", "search":
+		v, _ := e.args[0].eval(root, current)
+		p, _ := e.args[1].eval(root, current)
+		s, ok1 := v.(string)
+		pattern, ok2 := p.(string)
+		if !ok1 || !ok2 {
+			return false, true
+		}
+		if e.name == "match" {
+			pattern = "^(?:" + pattern + ")$"
+		}
+		re, err := regexp.Compile(pattern)
+		return err == nil && re.MatchString(s), true
+	}
+	return nil, false
+}
+
+// jpNumber returns v as a float64 if it is any Go number.
+func jpNumber(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// jpEqual compares two values as JSON, treating all numbers alike and two missing values as equal.
+func jpEqual(a any, aok bool, b any, bok bool) bool {
+	if !aok || !bok {
+		return aok == bok
+	}
+	if x, ok := jpNumber(a); ok {
+		y, ok := jpNumber(b)
+		return ok && x == y
+	}
+	if am, ok := mapOf(a); ok {
+		bm, ok := mapOf(b)
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for k, av := range am {
+			if bv, found := bm[k]; !found || !jpEqual(av, true, bv, true) {
+				return false
+			}
+		}
+		return true
+	}
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if ra.Kind() == reflect.Slice && rb.Kind() == reflect.Slice {
+		if ra.Len() != rb.Len() {
+			return false
+		}
+		for i := 0; i < ra.Len(); i++ {
+			if !jpEqual(ra.Index(i).Interface(), true, rb.Index(i).Interface(), true) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jpLess orders numbers and strings; every other pair compares false.
+func jpLess(a any, aok bool, b any, bok bool) bool {
+	if !aok || !bok {
+		return false
+	}
+	if x, ok := jpNumber(a); ok {
+		y, ok := jpNumber(b)
+		return ok && x < y
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		return ok && x < y
+	}
+	return false
+}
+
+// normalizedPath formats parts as an RFC 9535 normalized path.
+func normalizedPath(parts []pathPart) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, part := range parts {
+		if part.kind == partIndex {
+			sb.WriteString("[" + strconv.Itoa(part.index) + "]")
+			continue
+		}
+		sb.WriteString("['")
+		for _, r := range part.key {
+			switch r {
+			case '\'':
+				sb.WriteString(`\'`)
+			case '\\':
+				sb.WriteString(`\\`)
+			case '\b':
+				sb.WriteString(`\b`)
+			case '\f':
+				sb.WriteString(`\f`)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			case '\t':
+				sb.WriteString(`\t`)
+			default:
+				if r < 0x20 {
+					fmt.Fprintf(&sb, `\u%04x`, r)
+				} else {
+					sb.WriteRune(r)
+				}
+			}
+		}
+		sb.WriteString("']")
+	}
+	return sb.String()
+}
+
+type jpParser struct {
+	s string
+	i int
+}
+
+func (p *jpParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrPath, fmt.Sprintf(format, args...), p.i)
+}
+
+func (p *jpParser) ws() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *jpParser) peek(prefix string) bool {
+	return strings.HasPrefix(p.s[p.i:], prefix)
+}
+
+func (p *jpParser) accept(prefix string) bool {
+	if p.peek(prefix) {
+		p.i += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *jpParser) parseSegments() ([]jpSegment, error) {
+	var segments []jpSegment
+	for {
+		save := p.i
+		p.ws()
+		var seg jpSegment
+		var err error
+		switch {
+		case p.accept(".."):
+			seg.descendant = true
+			if p.peek("[") {
+				seg.selectors, err = p.parseBracket()
+			} else {
+				seg.selectors, err = p.parseShorthand()
+			}
+		case p.accept("."):
+			seg.selectors, err = p.parseShorthand()
+		case p.peek("["):
+			seg.selectors, err = p.parseBracket()
+		default:
+			p.i = save
+			return segments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+}
+
+// parseShorthand reads the * or member name following a dot.
+func (p *jpParser) parseShorthand() ([]jpSelector, error) {
+	if p.accept("*") {
+		return []jpSelector{{kind: jpWildcard}}, nil
+	}
+	start := p.i
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if c == '_' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || p.i > start && '0' <= c && c <= '9' {
+			p.i++
+		} else {
+			break
+		}
+	}
+	if p.i == start {
+		return nil, p.errorf("expected member name")
+	}
+	return []jpSelector{{kind: jpName, name: p.s[start:p.i]}}, nil
+}
+
+func (p *jpParser) parseBracket() ([]jpSelector, error) {
+	p.accept("[")
+	var selectors []jpSelector
+	for {
+		p.ws()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.ws()
+		if p.accept("]") {
+			return selectors, nil
+		}
+		if !p.accept(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *jpParser) parseSelector() (jpSelector, error) {
+	switch {
+	case p.peek("'") || p.peek(`"`):
+		name, err := p.parseString()
+		return jpSelector{kind: jpName, name: name}, err
+	case p.accept("*"):
+		return jpSelector{kind: jpWildcard}, nil
+	case p.accept("?"):
+		filter, err := p.parseOr()
+		return jpSelector{kind: jpFilter, filter: filter}, err
+	}
+
+	start, err := p.parseInt()
+	if err != nil {
+		return jpSelector{}, err
+	}
+	p.ws()
+	if !p.accept(":") {
+		if start == nil {
+			return jpSelector{}, p.errorf("expected selector")
+		}
+		return jpSelector{kind: jpIndex, index: *start}, nil
+	}
+	sel := jpSelector{kind: jpSlice, start: start}
+	p.ws()
+	if sel.end, err = p.parseInt(); err != nil {
+		return jpSelector{}, err
+	}
+	p.ws()
+	if p.accept(":") {
+		p.ws()
+		if sel.step, err = p.parseInt(); err != nil {
+			return jpSelector{}, err
+		}
+	}
+	return sel, nil
+}
+
+// parseInt reads an optional integer, returning nil if there is none.
+func (p *jpParser) parseInt() (*int, error) {
+	start := p.i
+	p.accept("-")
+	for p.i < len(p.s) && '0' <= p.s[p.i] && p.s[p.i] <= '9' {
+		p.i++
+	}
+	if p.i == start {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(p.s[start:p.i])
+	if err != nil {
+		return nil, p.errorf("invalid integer %q", p.s[start:p.i])
+	}
+	return &n, nil
+}
+
+// parseString reads a single or double quoted string literal with JSON style escapes.
+func (p *jpParser) parseString() (string, error) {
+	quote := p.s[p.i]
+	p.i++
+	var sb strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		if c == quote {
+			return sb.String(), nil
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		if p.i == len(p.s) {
+			break
+		}
+		c = p.s[p.i]
+		p.i++
+		switch c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			r, err := p.parseHex()
+			if err != nil {
+				return "", err
+			}
+			if 0xd800 <= r && r < 0xdc00 && p.accept(`\u`) {
+				low, err := p.parseHex()
+				if err != nil {
+					return "", err
+				}
+				r = 0x10000 + (r-0xd800)<<10 + (low - 0xdc00)
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jpParser) parseHex() (rune, error) {
+	if p.i+4 > len(p.s) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	n, err := strconv.ParseUint(p.s[p.i:p.i+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.i += 4
+	return rune(n), nil
+}
+
+func (p *jpParser) parseOr() (*jpExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil {
+		p.ws()
+		if !p.accept("||") {
+			return left, nil
+		}
+		var right *jpExpr
+		if right, err = p.parseAnd(); err == nil {
+			left = &jpExpr{op: "||", left: left, right: right}
+		}
+	}
+	return nil, err
+}
+
+func (p *jpParser) parseAnd() (*jpExpr, error) {
+	left, err := p.parseBasic()
+	for err == nil {
+		p.ws()
+		if !p.accept("&&") {
+			return left, nil
+		}
+		var right *jpExpr
+		if right, err = p.parseBasic(); err == nil {
+			left = &jpExpr{op: "&&", left: left, right: right}
+		}
+	}
+	return nil, err
+}
+
+func (p *jpParser) parseBasic() (*jpExpr, error) {
+	p.ws()
+	if p.accept("!") {
+		inner, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		return &jpExpr{op: "!", left: inner}, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.ws()
+		if !p.accept(")") {
+			return nil, p.errorf("expected )")
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+	p.ws()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parseComparable()
+			if err != nil {
+				return nil, err
+			}
+			return &jpExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	if left.op == "literal" {
+		return nil, p.errorf("expected comparison")
+	}
+	return &jpExpr{op: "test", left: left}, nil
+}
+
+func (p *jpParser) parseComparable() (*jpExpr, error) {
+	p.ws()
+	switch {
+	case p.peek("@") || p.peek("$"):
+		relative := p.s[p.i] == '@'
+		p.i++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return &jpExpr{op: "query", relative: relative, segments: segments}, nil
+	case p.peek("'") || p.peek(`"`):
+		s, err := p.parseString()
+		return &jpExpr{op: "literal", value: s}, err
+	case p.accept("true"):
+		return &jpExpr{op: "literal", value: true}, nil
+	case p.accept("false"):
+		return &jpExpr{op: "literal", value: false}, nil
+	case p.accept("null"):
+		return &jpExpr{op: "literal", value: nil}, nil
+	case p.i < len(p.s) && (p.s[p.i] == '-' || '0' <= p.s[p.i] && p.s[p.i] <= '9'):
+		start := p.i
+		for p.i < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.i]) >= 0 {
+			p.i++
+		}
+		f, err := strconv.ParseFloat(p.s[start:p.i], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.s[start:p.i])
+		}
+		return &jpExpr{op: "literal", value: f}, nil
+	}
+	return p.parseFunction()
+}
+
+// jpFunctions maps each function to its argument count.
+var jpFunctions = map[string]int{"length": 1, "count": 1, "value": 1, "match": 2, "search": 2}
+
+func (p *jpParser) parseFunction() (*jpExpr, error) {
+	start := p.i
+	for p.i < len(p.s) && ('a' <= p.s[p.i] && p.s[p.i] <= 'z' || p.s[p.i] == '_') {
+		p.i++
+	}
+	name := p.s[start:p.i]
+	argc, ok := jpFunctions[name]
+	if !ok || !p.accept("(") {
+		p.i = start
+		return nil, p.errorf("expected value")
+	}
+	e := &jpExpr{op: "func", name: name}
+	for {
+		p.ws()
+		if len(e.args) == 0 && p.accept(")") {
+			break
+		}
+		arg, err := p.parseComparable()
+		if err != nil {
+			return nil, err
+		}
+		e.args = append(e.args, arg)
+		p.ws()
+		if p.accept(")") {
+			break
+		}
+		if !p.accept(",") {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+	if len(e.args) != argc {
+		return nil, p.errorf("%s takes %d arguments", name, argc)
+	}
+	if (name == "count" || name == "value") && e.args[0].op != "query" {
+		return nil, p.errorf("%s takes a query", name)
+	}
+	return e, nil
+}
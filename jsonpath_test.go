@@ -0,0 +1,69 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPath(t *testing.T) {
+	o := Object{
+		"store": map[string]any{
+			"book": []any{
+				Object{"title": "Sayings", "price": 8.95, "tags": []string{"a"}},
+				Object{"title": "Sword", "price": 12.99, "isbn": "0-553"},
+				Object{"title": "Moby Dick", "price": 8, "isbn": "0-395"},
+			},
+			"bicycle": Object{"color": "red", "price": 399},
+		},
+		"devices": []Object{{"id": "a", "temp": 35, "on": true}, {"id": "b", "temp": 20}, {"id": "c", "temp": 31.5}},
+		"it's":    1,
+	}
+
+	paths := func(expr string) []string {
+		results, err := o.JSONPath(expr)
+		assert.NoError(t, err, expr)
+		var ret []string
+		for _, r := range results {
+			ret = append(ret, r.Path)
+		}
+		return ret
+	}
+
+	results, err := o.JSONPath("$.store.bicycle.color")
+	assert.NoError(t, err)
+	assert.Equal(t, []QueryResult{{Path: "$['store']['bicycle']['color']", Value: "red"}}, results)
+
+	assert.Equal(t, []string{"$['store']['book'][0]['title']", "$['store']['book'][1]['title']",
+		"$['store']['book'][2]['title']"}, paths("$.store.book[*].title"))
+	assert.Equal(t, []string{"$['store']['bicycle']['price']", "$['store']['book'][0]['price']",
+		"$['store']['book'][1]['price']", "$['store']['book'][2]['price']"}, paths("$..price"))
+	assert.Equal(t, []string{"$['store']['book'][2]"}, paths("$.store.book[-1]"))
+	assert.Equal(t, []string{"$['store']['book'][0]", "$['store']['book'][1]"}, paths("$.store.book[:2]"))
+	assert.Equal(t, []string{"$['store']['book'][2]", "$['store']['book'][0]"}, paths("$.store.book[::-2]"))
+	assert.Equal(t, []string{"$['store']['book'][0]", "$['store']['book'][2]"}, paths("$.store.book[0, 2]"))
+	assert.Equal(t, []string{"$['it\\'s']"}, paths(`$["it's"]`))
+
+	assert.Equal(t, []string{"$['devices'][0]", "$['devices'][2]"}, paths("$.devices[?(@.temp > 30)]"))
+	assert.Equal(t, []string{"$['devices'][0]"}, paths("$.devices[?@.temp > 30 && @.on == true]"))
+	assert.Equal(t, []string{"$['devices'][0]"}, paths("$.devices[?@.on]"))
+	assert.Equal(t, []string{"$['devices'][1]", "$['devices'][2]"}, paths("$.devices[?!@.on]"))
+	assert.Equal(t, []string{"$['devices'][1]"}, paths("$.devices[?@.id == 'b' || @.temp < 0]"))
+	assert.Equal(t, []string{"$['store']['book'][1]", "$['store']['book'][2]"}, paths("$.store.book[?@.isbn]"))
+	assert.Equal(t, []string{"$['store']['book'][2]"}, paths("$.store.book[?@.price < $.store.book[0].price]"))
+	assert.Equal(t, []string{"$['store']['book'][1]"}, paths("$.store.book[?match(@.title, 'S.*d')]"))
+	assert.Equal(t, []string{"$['store']['book'][0]", "$['store']['book'][1]"}, paths("$.store.book[?search(@.title, '^S')]"))
+	assert.Equal(t, []string{"$['store']['book'][2]"}, paths("$.store.book[?length(@.title) > 8]"))
+	assert.Equal(t, []string{"$['store']['book'][0]"}, paths("$.store.book[?count(@.tags[*]) == 1]"))
+	assert.Empty(t, paths("$.missing[*]"))
+
+	results, err = EvalJSONPath("$[1].a", []any{1, map[string]any{"a": []int{1, 2}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []QueryResult{{Path: "$[1]['a']", Value: []int{1, 2}}}, results)
+
+	for _, bad := range []string{"", "store", "$.", "$[", "$[1", "$['a", "$[?@.a ==]", "$[?1]", "$[?foo(@)]",
+		"$[?count(1) == 1]", "$.a b"} {
+		_, err := CompileJSONPath(bad)
+		assert.ErrorIs(t, err, ErrPath, bad)
+	}
+}
@@ -62,27 +62,17 @@ func MapKeysToArray[T any](m any) []T {
 	}
 }
 
+// FlattenMap flattens nested maps and arrays into a single map whose keys are the paths to each value, keeping empty
+// maps and arrays as values, as Object.Flatten does.  It always returns a new map.
 func FlattenMap(m map[string]any, delim string) map[string]any {
-	output := make(map[string]any)
+	return FlattenMapWith(m, FlattenOptions{Delim: delim, KeepEmpty: true})
+}
 
-	hasSubmaps := false
-	for _, value := range m {
-		if _, ok := value.(map[string]any); ok {
-			hasSubmaps = true
-		}
-	}
-	if !hasSubmaps {
-		return m
-	}
-	for key, value := range m {
-		if submap, ok := value.(map[string]any); ok {
-			flattenedSubmap := FlattenMap(submap, delim)
-			for subkey, subvalue := range flattenedSubmap {
-				output[key+delim+subkey] = subvalue
-			}
-		} else {
-			output[key] = value
-		}
-	}
+// FlattenMapWith is FlattenMap as options say, see Object.FlattenWith.
+func FlattenMapWith(m map[string]any, options FlattenOptions) map[string]any {
+	output := make(map[string]any)
+	flattenLeaves(m, options, func(path string, v any) {
+		output[path] = v
+	})
 	return output
 }
@@ -0,0 +1,216 @@
+package tox
+
+import (
+	"reflect"
+)
+
+// ArrayStrategy decides how MergeWith combines two arrays.
+type ArrayStrategy int
+
+const (
+	// ArrayReplace replaces the existing array.
+	ArrayReplace ArrayStrategy = iota
+	// ArrayAppend appends the new elements to the existing ones.
+	ArrayAppend
+	// ArrayUnion appends the new elements that are not already present.
+	ArrayUnion
+	// ArrayMergeByIndex merges elements at the same index and appends the rest.
+	ArrayMergeByIndex
+	// ArrayMergeByKey merges objects with the same value of MergeOptions.ArrayKey and appends the rest.
+	ArrayMergeByKey
+)
+
+// MergeRule overrides the merge options for the values at Path, in the syntax of Query, and everything below them.
+// Negative indexes and slice bounds are not supported in Path, and rules that use them are skipped like invalid ones.
+type MergeRule struct {
+	Path               string
+	KeepExisting       bool
+	OverwriteWithEmpty bool
+	Arrays             ArrayStrategy
+	ArrayKey           string
+}
+
+// MergeOptions controls MergeWith.
+type MergeOptions struct {
+	// KeepExisting only sets values that are missing or empty, like MergeMissing.
+	KeepExisting bool
+	// OverwriteWithEmpty lets empty values, such as nil, "", 0 or an empty array, replace existing ones, and nil
+	// values be added for missing keys.
+	OverwriteWithEmpty bool
+	Arrays             ArrayStrategy
+	ArrayKey           string
+	// Rules override the options for matching paths.  The first matching rule applies.
+	Rules []MergeRule
+}
+
+type merger struct {
+	rules    []MergeRule
+	patterns [][]pathPart
+}
+
+// MergeWith merges other into the object.  Objects are merged key by key, whether they are Object or map[string]any,
+// and arrays as options say.  Values taken from other are copied.
+func (o Object) MergeWith(other Object, options MergeOptions) {
+	if o == nil {
+		return
+	}
+	m := &merger{}
+	for _, rule := range options.Rules {
+		if parts, err := splitPattern(rule.Path); err == nil {
+			m.rules = append(m.rules, rule)
+			m.patterns = append(m.patterns, parts)
+		}
+	}
+	m.mergeMaps(o, other, nil, MergeRule{
+		KeepExisting:       options.KeepExisting,
+		OverwriteWithEmpty: options.OverwriteWithEmpty,
+		Arrays:             options.Arrays,
+		ArrayKey:           options.ArrayKey,
+	})
+}
+
+// rule returns the rule for at, which is the first matching rule or the one inherited from its parent.
+func (m *merger) rule(at []pathPart, inherited MergeRule) MergeRule {
+	for i, pattern := range m.patterns {
+		if matchQuery(pattern, at) {
+			return m.rules[i]
+		}
+	}
+	return inherited
+}
+
+func (m *merger) mergeMaps(dst map[string]any, src map[string]any, at []pathPart, rule MergeRule) {
+	for k, sv := range src {
+		part := appendPart(at, pathPart{kind: partKey, key: k})
+		dv, found := dst[k]
+		r := m.rule(part, rule)
+		if !found && sv == nil && !r.OverwriteWithEmpty {
+			continue
+		}
+		dst[k] = m.mergeValue(dv, found, sv, part, r)
+	}
+}
+
+func (m *merger) mergeValue(dv any, found bool, sv any, at []pathPart, rule MergeRule) any {
+	if dm, ok := mapOf(dv); ok {
+		if sm, ok := mapOf(sv); ok {
+			m.mergeMaps(dm, sm, at, rule)
+			return dv
+		}
+	}
+	rd, rs := reflect.ValueOf(dv), reflect.ValueOf(sv)
+	if found && isList(rd) && isList(rs) && rule.Arrays != ArrayReplace {
+		return m.mergeArrays(rd, rs, at, rule)
+	}
+	switch {
+	case !found:
+	case rule.KeepExisting && !isEmptyValue(dv):
+		return dv
+	case !rule.OverwriteWithEmpty && isEmptyValue(sv):
+		return dv
+	}
+	ret, _ := Deepcopy(sv)
+	return ret
+}
+
+func (m *merger) mergeArrays(rd reflect.Value, rs reflect.Value, at []pathPart, rule MergeRule) any {
+	values := make([]any, rd.Len())
+	for i := range values {
+		values[i] = rd.Index(i).Interface()
+	}
+	contains := func(v any) bool {
+		for _, e := range values {
+			if jpEqual(e, true, v, true) {
+				return true
+			}
+		}
+		return false
+	}
+	keys := make(map[string]int)
+	if rule.Arrays == ArrayMergeByKey {
+		for i, v := range values {
+			if k, ok := elementKey(v, rule.ArrayKey); ok {
+				keys[k] = i
+			}
+		}
+	}
+
+	for j := 0; j < rs.Len(); j++ {
+		sv := rs.Index(j).Interface()
+		i := -1
+		switch rule.Arrays {
+		case ArrayUnion:
+			if contains(sv) {
+				continue
+			}
+		case ArrayMergeByIndex:
+			if j < len(values) {
+				i = j
+			}
+		case ArrayMergeByKey:
+			if k, ok := elementKey(sv, rule.ArrayKey); ok {
+				if index, found := keys[k]; found {
+					i = index
+				} else {
+					keys[k] = len(values)
+				}
+			}
+		}
+		if i == -1 {
+			copied, _ := Deepcopy(sv)
+			values = append(values, copied)
+			continue
+		}
+		part := appendPart(at, pathPart{kind: partIndex, index: i})
+		values[i] = m.mergeValue(values[i], true, sv, part, m.rule(part, rule))
+	}
+	return makeList(rd.Type(), values)
+}
+
+// makeList returns values as a slice of type t when they all fit it, or as []any.
+func makeList(t reflect.Type, values []any) any {
+	if t.Kind() == reflect.Array {
+		t = reflect.SliceOf(t.Elem())
+	}
+	var ret any = reflect.MakeSlice(t, len(values), len(values)).Interface()
+	for i, v := range values {
+		ret = setIndex(reflect.ValueOf(ret), i, v)
+	}
+	return ret
+}
+
+// elementKey returns the value of key in an array element as a string, if the element is an object that has it.
+func elementKey(v any, key string) (string, bool) {
+	m, ok := mapOf(v)
+	if !ok || key == "" {
+		return "", false
+	}
+	k, found := m[key]
+	if !found || k == nil {
+		return "", false
+	}
+	return ToString(k), true
+}
+
+// isEmptyValue reports whether v is nil, false, zero, or an empty string, array or map.
+func isEmptyValue(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
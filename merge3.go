@@ -0,0 +1,330 @@
+package tox
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MergeStrategy decides how Merge3 resolves a conflict.
+type MergeStrategy int
+
+const (
+	// MergeFail reports conflicts as an error.
+	MergeFail MergeStrategy = iota
+	// MergeOurs resolves conflicts with our side.
+	MergeOurs
+	// MergeTheirs resolves conflicts with their side.
+	MergeTheirs
+	// MergeCustom resolves conflicts with Merge3Options.Resolver.
+	MergeCustom
+)
+
+// MergeArrays decides how Merge3 merges arrays changed on both sides.
+type MergeArrays int
+
+const (
+	// MergeArraysByIndex merges arrays element by element, as Diff compares them.
+	MergeArraysByIndex MergeArrays = iota
+	// MergeArraysWhole treats an array as a single value, so any change to it on both sides is a conflict.
+	MergeArraysWhole
+	// MergeArraysUnion keeps the elements added on either side and drops those removed on either side, without
+	// reporting a conflict.
+	MergeArraysUnion
+)
+
+// Merge3Options controls Merge3.
+type Merge3Options struct {
+	Strategy MergeStrategy
+	// Resolver returns the value to use for a conflict with MergeCustom, or false to delete the field.
+	Resolver func(conflict MergeConflict) (any, bool)
+	Arrays   MergeArrays
+	// Diff controls how values are compared, so that for example changes within a tolerance are not changes at all.
+	// Ignored paths keep their base value.  ArrayKey and ArrayLCS are not used.
+	Diff DiffOptions
+}
+
+// MergeConflict is a path changed differently on both sides.  The values are those at Path in each object, and the
+// In fields tell whether Path exists there.
+type MergeConflict struct {
+	Path     string
+	Base     any
+	Ours     any
+	Theirs   any
+	InBase   bool
+	InOurs   bool
+	InTheirs bool
+}
+
+type mergeChange struct {
+	key     string
+	parts   []pathPart
+	deleted bool
+	value   any
+}
+
+// Merge3 merges the changes made from base to ours and from base to theirs, using the keys of Diff.  Changes made on
+// one side only, or the same way on both, are merged; others are conflicts, which are resolved as options say.  When
+// a change on one side is within a value changed on the other, such as an object deleted on one side and edited on
+// the other, the conflict is at the outer path and is resolved by taking that whole value from one side.  Arrays
+// merged by index whose length both sides change, to different arrays, conflict as a whole.  The merged object is
+// returned along with every conflict found; with MergeFail, or a conflict left unresolved, the object is nil and the
+// error wraps ErrConflict.  A nil base, ours or theirs is taken as an empty object.
+func Merge3(base, ours, theirs Object, options Merge3Options) (Object, []MergeConflict, error) {
+	if base == nil {
+		base = Object{}
+	}
+	if ours == nil {
+		ours = Object{}
+	}
+	if theirs == nil {
+		theirs = Object{}
+	}
+	options.Diff.ArrayKey, options.Diff.ArrayLCS = "", false
+	d := newDiffer(options.Diff)
+	d.wholeArrays = options.Arrays != MergeArraysByIndex
+	ourChanges := mergeChanges(d.run(base, ours))
+	theirChanges := mergeChanges(d.run(base, theirs))
+
+	// find the outermost paths of conflicting changes
+	var roots [][]pathPart
+	for _, oc := range ourChanges {
+		for _, tc := range theirChanges {
+			shorter := oc.parts
+			if len(tc.parts) < len(shorter) {
+				shorter = tc.parts
+			}
+			if comparePaths(oc.parts[:len(shorter)], tc.parts[:len(shorter)]) != 0 {
+				continue
+			}
+			if len(oc.parts) == len(tc.parts) && oc.deleted == tc.deleted && (oc.deleted || d.equal(oc.value, tc.value)) {
+				continue
+			}
+			roots = append(roots, liftConflict(ours, theirs, shorter))
+		}
+	}
+	if options.Arrays == MergeArraysByIndex {
+		resizedArrays(d, map[string]any(base), map[string]any(ours), map[string]any(theirs), nil, &roots)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return comparePaths(roots[i], roots[j]) < 0
+	})
+	var outer [][]pathPart
+	for _, root := range roots {
+		if n := len(outer); n == 0 || !hasPathPrefix(root, outer[n-1]) {
+			outer = append(outer, root)
+		}
+	}
+	inConflict := func(parts []pathPart) bool {
+		for _, root := range outer {
+			if hasPathPrefix(parts, root) {
+				return true
+			}
+		}
+		return false
+	}
+
+	merged := base.Clone()
+	var changes []mergeChange
+	seen := make(map[string]bool)
+	for _, c := range append(ourChanges, theirChanges...) {
+		if !inConflict(c.parts) && !seen[c.key] {
+			seen[c.key] = true
+			changes = append(changes, c)
+		}
+	}
+
+	var conflicts []MergeConflict
+	unresolved := false
+	for _, root := range outer {
+		c := MergeConflict{Path: formatPath(root, "/")}
+		c.Base, c.InBase = getParts(base, root)
+		c.Ours, c.InOurs = getParts(ours, root)
+		c.Theirs, c.InTheirs = getParts(theirs, root)
+		if options.Arrays == MergeArraysUnion {
+			if union, ok := unionArrays(c); ok {
+				changes = append(changes, mergeChange{key: c.Path, parts: root, value: union})
+				continue
+			}
+		}
+		conflicts = append(conflicts, c)
+
+		change := mergeChange{key: c.Path, parts: root}
+		switch options.Strategy {
+		case MergeOurs:
+			change.value, change.deleted = c.Ours, !c.InOurs
+		case MergeTheirs:
+			change.value, change.deleted = c.Theirs, !c.InTheirs
+		case MergeCustom:
+			if options.Resolver == nil {
+				unresolved = true
+				continue
+			}
+			var keep bool
+			change.value, keep = options.Resolver(c)
+			change.deleted = !keep
+		default:
+			unresolved = true
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if unresolved {
+		return nil, conflicts, fmt.Errorf("%w: %d conflicting paths starting at %s", ErrConflict, len(conflicts), conflicts[0].Path)
+	}
+
+	// deletions from the highest array index down, then values from the outside in
+	sort.Slice(changes, func(i, j int) bool {
+		return comparePaths(changes[i].parts, changes[j].parts) < 0
+	})
+	for i := len(changes) - 1; i >= 0; i-- {
+		if c := changes[i]; c.deleted {
+			deleteParts(merged, c.parts)
+			for n := len(c.parts) - 1; n > 0; n-- {
+				if parent, _ := getParts(merged, c.parts[:n]); !isEmptyContainer(parent) {
+					break
+				}
+				deleteParts(merged, c.parts[:n])
+			}
+		}
+	}
+	for _, c := range changes {
+		if !c.deleted {
+			value, _ := Deepcopy(c.value)
+			if _, err := setParts(merged, c.parts, value); err != nil {
+				return nil, conflicts, fmt.Errorf("%w: cannot set %s", err, c.key)
+			}
+		}
+	}
+	return merged, conflicts, nil
+}
+
+// mergeChanges lists the changes of a diff made by Merge3, which has no array records.
+func mergeChanges(diff ObjectDiff) []mergeChange {
+	var changes []mergeChange
+	for _, k := range parseDiffKeys(diff.Deleted) {
+		changes = append(changes, mergeChange{key: k.key, parts: k.parts, deleted: true})
+	}
+	for _, k := range parseDiffKeys(diff.Modified) {
+		changes = append(changes, mergeChange{key: k.key, parts: k.parts, value: k.value.(FieldDiff).New})
+	}
+	for _, k := range parseDiffKeys(diff.Added) {
+		changes = append(changes, mergeChange{key: k.key, parts: k.parts, value: k.value})
+	}
+	return changes
+}
+
+// liftConflict moves a conflict at root out to the outermost path where ours and theirs differ in whether a value
+// exists or whether it is an Object, an array or neither, so that a value deleted or replaced on one side and edited
+// within on the other conflicts as a whole.
+func liftConflict(ours, theirs Object, root []pathPart) []pathPart {
+	shape := func(o Object, parts []pathPart) int {
+		v, found := getParts(o, parts)
+		switch _, ok := mapOf(v); {
+		case !found:
+			return 0
+		case ok:
+			return 1
+		case isList(reflect.ValueOf(v)):
+			return 2
+		default:
+			return 3
+		}
+	}
+	for n := 1; n < len(root); n++ {
+		if shape(ours, root[:n]) != shape(theirs, root[:n]) {
+			return root[:n]
+		}
+	}
+	return root
+}
+
+// resizedArrays adds to roots the path of every array merged by index whose length ours and theirs both change, unless
+// they change it to the same array, since merging such arrays element by element shifts or drops elements.
+func resizedArrays(d *differ, base, ours, theirs any, at []pathPart, roots *[][]pathPart) {
+	if d.ignored(at) {
+		return
+	}
+	om, ook := mapOf(ours)
+	tm, tok := mapOf(theirs)
+	if ook && tok {
+		bm, _ := mapOf(base)
+		for k, ov := range om {
+			if tv, found := tm[k]; found {
+				resizedArrays(d, bm[k], ov, tv, appendPart(at, pathPart{kind: partKey, key: k}), roots)
+			}
+		}
+		return
+	}
+
+	// as in Diff, only arrays that are the value of a key are merged element by element
+	ro, rt, rb := reflect.ValueOf(ours), reflect.ValueOf(theirs), reflect.ValueOf(base)
+	if len(at) == 0 || at[len(at)-1].kind != partKey || !isList(ro) || !isList(rt) {
+		return
+	}
+	n := 0
+	if isList(rb) {
+		n = rb.Len()
+	}
+	if ro.Len() != n && rt.Len() != n {
+		same := newDiffer(d.options)
+		same.wholeArrays = d.wholeArrays
+		same.compare(ours, theirs, at)
+		if !same.empty() {
+			*roots = append(*roots, at)
+		}
+		return
+	}
+	for i := 0; i < ro.Len() && i < rt.Len(); i++ {
+		var bv any
+		if i < n {
+			bv = rb.Index(i).Interface()
+		}
+		index := appendPart(at, pathPart{kind: partIndex, index: i})
+		resizedArrays(d, bv, ro.Index(i).Interface(), rt.Index(i).Interface(), index, roots)
+	}
+}
+
+func hasPathPrefix(parts []pathPart, prefix []pathPart) bool {
+	return len(parts) >= len(prefix) && comparePaths(parts[:len(prefix)], prefix) == 0
+}
+
+// unionArrays merges the arrays of a conflict, keeping the order of ours followed by the elements only in theirs, and
+// dropping the elements of base that either side removed.  It returns false unless both sides are arrays.
+func unionArrays(c MergeConflict) ([]any, bool) {
+	list := func(v any) ([]any, bool) {
+		rv := reflect.ValueOf(v)
+		if !isList(rv) {
+			return nil, false
+		}
+		ret := make([]any, rv.Len())
+		for i := range ret {
+			ret[i] = rv.Index(i).Interface()
+		}
+		return ret, true
+	}
+	contains := func(list []any, v any) bool {
+		for _, e := range list {
+			if jpEqual(e, true, v, true) {
+				return true
+			}
+		}
+		return false
+	}
+	ours, ok1 := list(c.Ours)
+	theirs, ok2 := list(c.Theirs)
+	base, ok3 := list(c.Base)
+	if !ok1 || !ok2 || (c.InBase && !ok3) {
+		return nil, false
+	}
+	removed := func(v any) bool {
+		return contains(base, v) && (!contains(ours, v) || !contains(theirs, v))
+	}
+	ret := []any{}
+	for _, v := range append(ours, theirs...) {
+		if !removed(v) && !contains(ret, v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret, true
+}
@@ -0,0 +1,153 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	base := Object{
+		"name":   "dev",
+		"rate":   10,
+		"mode":   "auto",
+		"net":    Object{"ip": "10.0.0.1", "mask": 24},
+		"tags":   []any{"a", "b"},
+		"remove": Object{"x": 1},
+	}
+	ours := Object{
+		"name":   "dev",
+		"rate":   20,
+		"mode":   "manual",
+		"net":    Object{"ip": "10.0.0.2", "mask": 24},
+		"tags":   []any{"a", "b", "c"},
+		"remove": Object{"x": 5},
+		"local":  true,
+	}
+	theirs := Object{
+		"name":  "device",
+		"rate":  20,
+		"mode":  "off",
+		"net":   Object{"ip": "10.0.0.1", "mask": 16},
+		"tags":  []any{"b", "d"},
+		"cloud": 1,
+	}
+
+	merged, conflicts, err := Merge3(base, ours, theirs, Merge3Options{})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Nil(t, merged)
+	assert.Equal(t, []MergeConflict{
+		{Path: "mode", Base: "auto", Ours: "manual", Theirs: "off", InBase: true, InOurs: true, InTheirs: true},
+		{Path: "remove", Base: Object{"x": 1}, Ours: Object{"x": 5}, InBase: true, InOurs: true},
+	}, conflicts)
+
+	// by index, the changes to tags touch different elements and do not conflict
+	merged, _, err = Merge3(base, ours, theirs, Merge3Options{Strategy: MergeOurs})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"b", "d", "c"}, merged["tags"])
+
+	merged, conflicts, err = Merge3(base, ours, theirs, Merge3Options{Strategy: MergeOurs, Arrays: MergeArraysUnion})
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 2)
+	assert.Equal(t, Object{
+		"name":   "device",
+		"rate":   20,
+		"mode":   "manual",
+		"net":    Object{"ip": "10.0.0.2", "mask": 16},
+		"tags":   []any{"b", "c", "d"},
+		"remove": Object{"x": 5},
+		"local":  true,
+		"cloud":  1,
+	}, merged)
+
+	merged, _, err = Merge3(base, ours, theirs, Merge3Options{Strategy: MergeTheirs, Arrays: MergeArraysWhole})
+	assert.NoError(t, err)
+	assert.Equal(t, "off", merged["mode"])
+	assert.Equal(t, []any{"b", "d"}, merged["tags"])
+	assert.False(t, merged.Exists("remove"))
+
+	merged, conflicts, err = Merge3(base, ours, theirs, Merge3Options{
+		Strategy: MergeCustom,
+		Arrays:   MergeArraysWhole,
+		Resolver: func(c MergeConflict) (any, bool) {
+			if c.Path == "mode" {
+				return ToString(c.Ours) + "/" + ToString(c.Theirs), true
+			}
+			return nil, false
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 3)
+	assert.Equal(t, "manual/off", merged["mode"])
+	assert.False(t, merged.Exists("tags"))
+	assert.False(t, merged.Exists("remove"))
+
+	// changes within the tolerance are not conflicts, and ignored paths keep their base value
+	merged, conflicts, err = Merge3(Object{"t": 20.0, "ts": 1}, Object{"t": 20.001, "ts": 2}, Object{"t": 19.999, "ts": 3},
+		Merge3Options{Diff: DiffOptions{Epsilon: 0.01, Ignore: []string{"ts"}}})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, Object{"t": 20.0, "ts": 1}, merged)
+
+}
+
+func TestMerge3Nil(t *testing.T) {
+	merged, conflicts, err := Merge3(Object{"a": 1}, nil, Object{"a": 1, "b": 2}, Merge3Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, Object{"b": 2}, merged)
+
+	merged, _, err = Merge3(nil, Object{"a": 1}, nil, Merge3Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, Object{"a": 1}, merged)
+}
+
+func TestMerge3Arrays(t *testing.T) {
+	base := Object{"list": []any{1, 2, 3, 4}}
+	merged, conflicts, err := Merge3(base, Object{"list": []any{1, 2, 3}}, Object{"list": []any{1, 2, 3, 4, 5}},
+		Merge3Options{})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Nil(t, merged)
+	assert.Equal(t, []MergeConflict{{
+		Path:     "list",
+		Base:     []any{1, 2, 3, 4},
+		Ours:     []any{1, 2, 3},
+		Theirs:   []any{1, 2, 3, 4, 5},
+		InBase:   true,
+		InOurs:   true,
+		InTheirs: true,
+	}}, conflicts)
+
+	// arrays within objects within arrays, and arrays resized the same way on both sides
+	base = Object{"items": []any{Object{"tags": []any{"a"}}}, "same": []any{1}}
+	ours := Object{"items": []any{Object{"tags": []any{"a", "b"}}}, "same": []any{1, 2}}
+	theirs := Object{"items": []any{Object{"tags": []any{}}}, "same": []any{1, 2}}
+	merged, conflicts, err = Merge3(base, ours, theirs, Merge3Options{Strategy: MergeTheirs})
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "items[0]/tags", conflicts[0].Path)
+	assert.Equal(t, Object{"items": []any{Object{"tags": []any{}}}, "same": []any{1, 2}}, merged)
+
+	// a length changed on one side only merges by index
+	merged, _, err = Merge3(base, ours, base, Merge3Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, ours, merged)
+}
+
+func TestMerge3Containers(t *testing.T) {
+	// an object deleted on one side and edited on the other conflicts as a whole
+	base := Object{"o": Object{"x": 1, "y": 2}}
+	merged, conflicts, err := Merge3(base, Object{}, Object{"o": Object{"x": 3, "y": 2}}, Merge3Options{})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Nil(t, merged)
+	assert.Equal(t, []MergeConflict{
+		{Path: "o", Base: Object{"x": 1, "y": 2}, Theirs: Object{"x": 3, "y": 2}, InBase: true, InTheirs: true},
+	}, conflicts)
+
+	// empty objects and arrays added on one side are kept
+	merged, conflicts, err = Merge3(Object{"a": 1}, Object{"a": 1, "cfg": Object{}},
+		Object{"a": 2, "list": []any{}, "n": Object{"o": Object{}}}, Merge3Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, Object{"a": 2, "cfg": Object{}, "list": []any{}, "n": Object{"o": Object{}}}, merged)
+}
@@ -0,0 +1,87 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	o := Object{"a": 1, "b": "x", "nested": Object{"c": 1, "d": 2}, "list": []any{1, 2}, "zero": 0}
+	o.Merge(Object{"a": 2, "b": "", "nested": map[string]any{"d": 3, "e": 4}, "list": []any{3}, "zero": 5, "new": true})
+	assert.Equal(t, Object{"a": 2, "b": "", "nested": Object{"c": 1, "d": 3, "e": 4}, "list": []any{3}, "zero": 5,
+		"new": true}, o)
+
+	o = Object{"on": true, "count": 3, "name": "x", "list": []any{1}}
+	o.Merge(Object{"on": false, "count": 0, "name": "", "list": []any{}, "gone": nil})
+	assert.Equal(t, Object{"on": false, "count": 0, "name": "", "list": []any{}, "gone": nil}, o)
+
+	o = Object{"a": 1, "b": "x"}
+	o.MergeWith(Object{"a": 0, "b": "", "c": nil}, MergeOptions{})
+	assert.Equal(t, Object{"a": 1, "b": "x"}, o)
+
+	o = Object{"a": 1, "zero": 0, "nested": map[string]any{"c": 1}}
+	o.MergeMissing(Object{"a": 2, "zero": 5, "nested": Object{"c": 2, "d": 3}, "new": "n"})
+	assert.Equal(t, Object{"a": 1, "zero": 5, "nested": map[string]any{"c": 1, "d": 3}, "new": "n"}, o)
+
+	o = Object{}
+	o.MergeMissing(Object{"a": nil, "b": 1})
+	assert.Equal(t, Object{"b": 1}, o)
+
+	o = Object{"a": 1}
+	o.MergeWith(Object{"a": 0, "b": nil}, MergeOptions{OverwriteWithEmpty: true})
+	assert.Equal(t, Object{"a": 0, "b": nil}, o)
+}
+
+func TestMergeWithArrays(t *testing.T) {
+	base := func() Object {
+		return Object{
+			"tags":    []string{"a", "b"},
+			"points":  []any{Object{"x": 1, "y": 1}, Object{"x": 2}},
+			"devices": []any{Object{"id": "d1", "on": false, "name": "one"}, Object{"id": "d2", "on": true}},
+		}
+	}
+	other := Object{
+		"tags":    []string{"b", "c"},
+		"points":  []any{Object{"y": 5}, Object{"y": 6}, Object{"x": 3}},
+		"devices": []any{map[string]any{"id": "d2", "on": false}, Object{"id": "d3"}, Object{"id": "d1", "on": true}},
+	}
+
+	o := base()
+	o.MergeWith(other, MergeOptions{Arrays: ArrayAppend})
+	assert.Equal(t, []string{"a", "b", "b", "c"}, o["tags"])
+	assert.Len(t, o["devices"], 5)
+
+	o = base()
+	o.MergeWith(other, MergeOptions{Arrays: ArrayUnion})
+	assert.Equal(t, []string{"a", "b", "c"}, o["tags"])
+
+	o = base()
+	o.MergeWith(other, MergeOptions{Arrays: ArrayMergeByIndex})
+	assert.Equal(t, []any{Object{"x": 1, "y": 5}, Object{"x": 2, "y": 6}, Object{"x": 3}}, o["points"])
+
+	o = base()
+	o.MergeWith(other, MergeOptions{Arrays: ArrayMergeByKey, ArrayKey: "id", OverwriteWithEmpty: true})
+	assert.Equal(t, []any{
+		Object{"id": "d1", "on": true, "name": "one"},
+		Object{"id": "d2", "on": false},
+		Object{"id": "d3"},
+	}, o["devices"])
+
+	o = base()
+	o.MergeWith(other, MergeOptions{
+		Arrays: ArrayUnion,
+		Rules: []MergeRule{
+			{Path: "points", Arrays: ArrayMergeByIndex},
+			{Path: "devices[*]", KeepExisting: true},
+			{Path: "devices", Arrays: ArrayMergeByKey, ArrayKey: "id"},
+		},
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, o["tags"])
+	assert.Equal(t, Object{"x": 1, "y": 5}, o.Get("points[0]"))
+	assert.Equal(t, Object{"id": "d1", "on": true, "name": "one"}, o.Get("devices[0]"))
+	assert.Equal(t, Object{"id": "d2", "on": true}, o.Get("devices[1]"))
+
+	other.Set("devices[2].name", "changed")
+	assert.Equal(t, "one", o.Get("devices[0].name"))
+}
@@ -0,0 +1,65 @@
+package tox
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to the object: a null (or Null) value deletes the key, an object
+// is merged recursively and any other value, arrays included, replaces the current one.  Values are copied from the
+// patch, so later changes to either side do not affect the other.
+func (o Object) MergePatch(patch Object) {
+	if o == nil {
+		return
+	}
+	mergePatch(o, patch)
+}
+
+func mergePatch(target any, patch any) any {
+	pm, ok := mapOf(patch)
+	if !ok {
+		ret, _ := Deepcopy(patch)
+		return ret
+	}
+	tm, ok := mapOf(target)
+	if !ok {
+		obj := Object{}
+		tm, target = obj, obj
+	}
+	for k, v := range pm {
+		if v == nil || v == Null {
+			delete(tm, k)
+		} else {
+			tm[k] = mergePatch(tm[k], v)
+		}
+	}
+	return target
+}
+
+// CreateMergePatch returns the RFC 7386 JSON Merge Patch that turns the object into target.  Merge patches cannot
+// set a value to null, so a key that is null in target is deleted by the patch instead.
+func (o Object) CreateMergePatch(target Object) Object {
+	return createMergePatch(o, target)
+}
+
+func createMergePatch(from map[string]any, to map[string]any) Object {
+	patch := Object{}
+	for k := range from {
+		if _, found := to[k]; !found {
+			patch[k] = nil
+		}
+	}
+	for k, tv := range to {
+		fv, found := from[k]
+		fm, fok := mapOf(fv)
+		tm, tok := mapOf(tv)
+		switch {
+		case tv == nil:
+			if found && fv != nil {
+				patch[k] = nil
+			}
+		case found && fok && tok:
+			if sub := createMergePatch(fm, tm); len(sub) > 0 {
+				patch[k] = sub
+			}
+		case !found || !jpEqual(fv, true, tv, true):
+			patch[k], _ = Deepcopy(tv)
+		}
+	}
+	return patch
+}
@@ -0,0 +1,52 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// the examples of RFC 7386 appendix A
+	tests := []struct {
+		target, patch, want Object
+	}{
+		{Object{"a": "b"}, Object{"a": "c"}, Object{"a": "c"}},
+		{Object{"a": "b"}, Object{"b": "c"}, Object{"a": "b", "b": "c"}},
+		{Object{"a": "b"}, Object{"a": nil}, Object{}},
+		{Object{"a": "b", "b": "c"}, Object{"a": nil}, Object{"b": "c"}},
+		{Object{"a": []any{"b"}}, Object{"a": "c"}, Object{"a": "c"}},
+		{Object{"a": "c"}, Object{"a": []any{"b"}}, Object{"a": []any{"b"}}},
+		{Object{"a": Object{"b": "c"}}, Object{"a": Object{"b": "d", "c": nil}}, Object{"a": Object{"b": "d"}}},
+		{Object{"a": []any{Object{"b": "c"}}}, Object{"a": []any{1}}, Object{"a": []any{1}}},
+		{Object{"e": nil}, Object{"a": 1}, Object{"e": nil, "a": 1}},
+		{Object{}, Object{"a": Object{"bb": Object{"ccc": nil}}}, Object{"a": Object{"bb": Object{}}}},
+		{Object{"a": "b"}, Object{"a": Null}, Object{}},
+		{Object{"a": Object{}}, Object{"a": map[string]any{}}, Object{"a": Object{}}},
+	}
+	for _, tt := range tests {
+		tt.target.MergePatch(tt.patch)
+		assert.Equal(t, tt.want, tt.target)
+	}
+
+	patch := Object{"list": []any{1, 2}}
+	o := Object{}
+	o.MergePatch(patch)
+	patch["list"].([]any)[0] = 9
+	assert.Equal(t, []any{1, 2}, o["list"])
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	from := Object{"title": "Goodbye!", "author": Object{"given": "John", "family": "Doe"}, "tags": []any{"a", "b"},
+		"content": "x", "gone": nil}
+	to := Object{"title": "Hello!", "author": Object{"given": "John"}, "tags": []any{"a"}, "content": "x",
+		"phone": "555", "gone": nil}
+
+	patch := from.CreateMergePatch(to)
+	assert.Equal(t, Object{"title": "Hello!", "author": Object{"family": nil}, "tags": []any{"a"}, "phone": "555"}, patch)
+
+	from.MergePatch(patch)
+	assert.Equal(t, Object{"title": "Hello!", "author": Object{"given": "John"}, "tags": []any{"a"}, "content": "x",
+		"phone": "555", "gone": nil}, from)
+	assert.Empty(t, from.CreateMergePatch(to))
+}
@@ -0,0 +1,735 @@
+package tox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConflict is returned by ObjectDiff.Apply when the object no longer holds the values the diff was made from.
+var ErrConflict = errors.New("diff conflict")
+
+// DiffOptions controls how DiffWith compares two objects.  The zero value compares exactly like Diff.
+type DiffOptions struct {
+	// Ignore lists paths to leave out of the diff, in the syntax of Query, such as "meta", "items[*].updatedAt" or
+	// "..timestamp".  An ignored path hides everything below it.  Negative indexes and slice bounds, such as "[-1]" or
+	// "[-2:]", are not supported, and patterns that use them are skipped like invalid ones.
+	Ignore []string
+	// Epsilon is the largest absolute difference at which two numbers are still equal.
+	Epsilon float64
+	// RelativeTolerance is the largest difference, relative to the larger of two numbers, at which they are still
+	// equal.
+	RelativeTolerance float64
+	// LooseTypes compares values regardless of their types, so 1, 1.0 and "1" are equal and so are true and "true".
+	LooseTypes bool
+	// TimePrecision compares times truncated to the given precision.
+	TimePrecision time.Duration
+	// ArrayKey matches array elements by the value of this key, such as "id", instead of by index.  Elements that
+	// are not objects or lack the key are matched by ArrayLCS, or not at all.
+	ArrayKey string
+	// ArrayLCS matches array elements that are equal by their longest common subsequence instead of by index.
+	ArrayLCS bool
+}
+
+// Array change operations.
+const (
+	ArrayInsert = "insert"
+	ArrayDelete = "delete"
+	ArrayMove   = "move"
+)
+
+// ArrayChange is one element inserted into, deleted from or moved within an array.  From is the index in the old
+// array and To the index in the new one, and Value is the inserted or deleted element.
+type ArrayChange struct {
+	Op    string `json:"op"              bson:"op"`
+	From  int    `json:"from"            bson:"from"`
+	To    int    `json:"to"              bson:"to"`
+	Value any    `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+// ArrayDiff records how DiffWith matched the elements of an array when ArrayKey or ArrayLCS is set.  Elements that
+// are neither moved, inserted nor deleted keep their order and fill the remaining positions, and the changes within
+// matched elements are recorded in the ObjectDiff under their index in the new array.
+type ArrayDiff struct {
+	OldLength int           `json:"oldLength" bson:"oldLength"`
+	NewLength int           `json:"newLength" bson:"newLength"`
+	Changes   []ArrayChange `json:"changes"   bson:"changes"`
+}
+
+// DiffConflict is a field whose current value does not match the diff.  Expected is the value the diff was made from,
+// or nil for an added field that was expected to be missing, and Found tells whether the field exists at all.
+type DiffConflict struct {
+	Path     string
+	Expected any
+	Actual   any
+	Found    bool
+}
+
+// ConflictError lists every conflict found by ObjectDiff.Apply.
+type ConflictError struct {
+	Conflicts []DiffConflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("tox: diff does not apply, %d conflicting fields starting at %s", len(e.Conflicts), e.Conflicts[0].Path)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// DiffWith compares the object to other like Diff, with the comparison controlled by options.  Like Diff, two nil
+// objects are the same and a nil object differs from any other without further detail.
+func (o Object) DiffWith(other Object, options DiffOptions) ObjectDiff {
+	if o == nil && other == nil {
+		return ObjectDiff{Same: true}
+	} else if o == nil || other == nil {
+		return ObjectDiff{Same: false}
+	}
+	return newDiffer(options).run(o, other)
+}
+
+func newDiffer(options DiffOptions) *differ {
+	d := &differ{options: options}
+	for _, pattern := range options.Ignore {
+		if parts, err := splitPattern(pattern); err == nil {
+			d.ignore = append(d.ignore, parts)
+		}
+	}
+	return d
+}
+
+func (d *differ) run(a Object, b Object) ObjectDiff {
+	d.diff = ObjectDiff{}
+	d.compare(map[string]any(a), map[string]any(b), nil)
+	d.diff.Same = d.empty()
+	return d.diff
+}
+
+type differ struct {
+	options DiffOptions
+	ignore  [][]pathPart
+	diff    ObjectDiff
+	// wholeArrays compares arrays as single values instead of element by element
+	wholeArrays bool
+}
+
+func (d *differ) empty() bool {
+	return d.diff.Added == nil && d.diff.Modified == nil && d.diff.Deleted == nil && d.diff.Arrays == nil
+}
+
+func (d *differ) ignored(at []pathPart) bool {
+	for _, pattern := range d.ignore {
+		if matchQuery(pattern, at) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare records the differences between a and b at the path at, with the keys described on Object.Diff.
+func (d *differ) compare(a any, b any, at []pathPart) {
+	if d.ignored(at) {
+		return
+	}
+	am, aok := mapOf(a)
+	bm, bok := mapOf(b)
+	if aok && bok {
+		for k, av := range am {
+			part := appendPart(at, pathPart{kind: partKey, key: k})
+			if bv, found := bm[k]; found {
+				d.compare(av, bv, part)
+			} else {
+				d.leaves(av, part, d.deleted)
+			}
+		}
+		for k, bv := range bm {
+			if _, found := am[k]; !found {
+				d.leaves(bv, appendPart(at, pathPart{kind: partKey, key: k}), d.added)
+			}
+		}
+		d.emptied(a, b, len(am), len(bm), at)
+		return
+	}
+
+	// only arrays that are the value of a key are compared element by element, arrays within arrays are single values
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	expandable := !d.wholeArrays && len(at) > 0 && at[len(at)-1].kind == partKey
+	if expandable && isList(ra) && isList(rb) {
+		d.compareArrays(ra, rb, at)
+		return
+	}
+	if aok || bok || expandable && (isList(ra) || isList(rb)) {
+		d.leaves(a, at, d.deleted)
+		d.leaves(b, at, d.added)
+		return
+	}
+	if !d.equal(a, b) {
+		if d.diff.Modified == nil {
+			d.diff.Modified = make(map[string]FieldDiff)
+		}
+		d.diff.Modified[formatPath(at, "/")] = FieldDiff{Old: a, New: b}
+	}
+}
+
+// leaves calls fn with the key and value of every leaf of v at the path at, including empty Objects and arrays.
+func (d *differ) leaves(v any, at []pathPart, fn func(key string, v any)) {
+	if d.ignored(at) {
+		return
+	}
+	if m, ok := mapOf(v); ok && len(m) > 0 {
+		for k, mv := range m {
+			d.leaves(mv, appendPart(at, pathPart{kind: partKey, key: k}), fn)
+		}
+		return
+	}
+	// as in compare, arrays are only expanded when they are the value of a key
+	if rv := reflect.ValueOf(v); !d.wholeArrays && len(at) > 0 && at[len(at)-1].kind == partKey && isList(rv) &&
+		rv.Len() > 0 {
+		for i := 0; i < rv.Len(); i++ {
+			d.leaves(rv.Index(i).Interface(), appendPart(at, pathPart{kind: partIndex, index: i}), fn)
+		}
+		return
+	}
+	fn(formatPath(at, "/"), v)
+}
+
+// emptied records an Object or array at the path at that goes from n elements in a to m in b, when either is empty.
+// An emptied b is recorded as added, so Apply puts it back after removing the container its deleted elements left
+// empty, and an empty a as deleted, which makes the change reversible.
+func (d *differ) emptied(a any, b any, n int, m int, at []pathPart) {
+	switch {
+	case len(at) == 0:
+	case n > 0 && m == 0:
+		d.added(formatPath(at, "/"), b)
+	case n == 0 && m > 0:
+		d.deleted(formatPath(at, "/"), a)
+	}
+}
+
+func (d *differ) added(key string, v any) {
+	if d.diff.Added == nil {
+		d.diff.Added = Object{}
+	}
+	d.diff.Added[key] = v
+}
+
+func (d *differ) deleted(key string, v any) {
+	if d.diff.Deleted == nil {
+		d.diff.Deleted = Object{}
+	}
+	d.diff.Deleted[key] = v
+}
+
+func (d *differ) compareArrays(ra reflect.Value, rb reflect.Value, at []pathPart) {
+	element := func(rv reflect.Value, i int) any {
+		return rv.Index(i).Interface()
+	}
+	index := func(i int) []pathPart {
+		return appendPart(at, pathPart{kind: partIndex, index: i})
+	}
+
+	pairs := d.matchArrays(ra, rb, at)
+	if pairs == nil {
+		for i := 0; i < ra.Len() || i < rb.Len(); i++ {
+			switch {
+			case i >= rb.Len():
+				d.leaves(element(ra, i), index(i), d.deleted)
+			case i >= ra.Len():
+				d.leaves(element(rb, i), index(i), d.added)
+			default:
+				d.compare(element(ra, i), element(rb, i), index(i))
+			}
+		}
+		d.emptied(ra.Interface(), rb.Interface(), ra.Len(), rb.Len(), at)
+		return
+	}
+
+	ad := ArrayDiff{OldLength: ra.Len(), NewLength: rb.Len()}
+	matchedA := make([]bool, ra.Len())
+	matchedB := make([]bool, rb.Len())
+	for _, p := range pairs {
+		matchedA[p[0]], matchedB[p[1]] = true, true
+	}
+	for i := 0; i < ra.Len(); i++ {
+		if !matchedA[i] {
+			ad.Changes = append(ad.Changes, ArrayChange{Op: ArrayDelete, From: i, To: -1, Value: element(ra, i)})
+		}
+	}
+	for _, i := range movedPairs(pairs) {
+		ad.Changes = append(ad.Changes, ArrayChange{Op: ArrayMove, From: pairs[i][0], To: pairs[i][1]})
+	}
+	for j := 0; j < rb.Len(); j++ {
+		if !matchedB[j] {
+			ad.Changes = append(ad.Changes, ArrayChange{Op: ArrayInsert, From: -1, To: j, Value: element(rb, j)})
+		}
+	}
+	for _, p := range pairs {
+		d.compare(element(ra, p[0]), element(rb, p[1]), index(p[1]))
+	}
+	if len(ad.Changes) > 0 {
+		if d.diff.Arrays == nil {
+			d.diff.Arrays = make(map[string]ArrayDiff)
+		}
+		d.diff.Arrays[formatPath(at, "/")] = ad
+	}
+}
+
+// matchArrays pairs the elements of two arrays as (old index, new index), ordered by new index, or returns nil when
+// arrays are matched by index.
+func (d *differ) matchArrays(ra reflect.Value, rb reflect.Value, at []pathPart) [][2]int {
+	if d.options.ArrayKey == "" && !d.options.ArrayLCS {
+		return nil
+	}
+	pairs := [][2]int{}
+	matchedA := make([]bool, ra.Len())
+	matchedB := make([]bool, rb.Len())
+	if d.options.ArrayKey != "" {
+		keys := make(map[string]int)
+		for i := 0; i < ra.Len(); i++ {
+			if k, ok := elementKey(ra.Index(i).Interface(), d.options.ArrayKey); ok {
+				if _, dup := keys[k]; !dup {
+					keys[k] = i
+				}
+			}
+		}
+		for j := 0; j < rb.Len(); j++ {
+			if k, ok := elementKey(rb.Index(j).Interface(), d.options.ArrayKey); ok {
+				if i, found := keys[k]; found {
+					pairs = append(pairs, [2]int{i, j})
+					matchedA[i], matchedB[j] = true, true
+					delete(keys, k)
+				}
+			}
+		}
+	}
+	if d.options.ArrayLCS {
+		var restA, restB []int
+		for i := range matchedA {
+			if !matchedA[i] {
+				restA = append(restA, i)
+			}
+		}
+		for j := range matchedB {
+			if !matchedB[j] {
+				restB = append(restB, j)
+			}
+		}
+		pairs = append(pairs, d.lcs(ra, rb, restA, restB, at)...)
+		sort.Slice(pairs, func(x, y int) bool {
+			return pairs[x][1] < pairs[y][1]
+		})
+	}
+	return pairs
+}
+
+// lcs returns the longest common subsequence of the elements of ra at indexes as and of rb at indexes bs.
+func (d *differ) lcs(ra reflect.Value, rb reflect.Value, as []int, bs []int, at []pathPart) [][2]int {
+	n, m := len(as), len(bs)
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	equal := make([][]bool, n)
+	for i := n - 1; i >= 0; i-- {
+		equal[i] = make([]bool, m)
+		for j := m - 1; j >= 0; j-- {
+			sub := &differ{options: d.options, ignore: d.ignore}
+			sub.compare(ra.Index(as[i]).Interface(), rb.Index(bs[j]).Interface(), appendPart(at, pathPart{kind: partIndex, index: bs[j]}))
+			if equal[i][j] = sub.empty(); equal[i][j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal[i][j]:
+			pairs = append(pairs, [2]int{as[i], bs[j]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// movedPairs returns the positions in pairs, ordered by new index, of the elements that have to move: all but the
+// longest run whose old indexes are increasing.
+func movedPairs(pairs [][2]int) []int {
+	n := len(pairs)
+	lengths := make([]int, n)
+	prev := make([]int, n)
+	best := -1
+	for i := range pairs {
+		lengths[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if pairs[j][0] < pairs[i][0] && lengths[j]+1 > lengths[i] {
+				lengths[i], prev[i] = lengths[j]+1, j
+			}
+		}
+		if best == -1 || lengths[i] > lengths[best] {
+			best = i
+		}
+	}
+	kept := make([]bool, n)
+	for i := best; i >= 0; i = prev[i] {
+		kept[i] = true
+	}
+	var moved []int
+	for i := range pairs {
+		if !kept[i] {
+			moved = append(moved, i)
+		}
+	}
+	return moved
+}
+
+func isList(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array
+}
+
+// equal compares two leaf values.
+func (d *differ) equal(a any, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	options := d.options
+	if options.TimePrecision > 0 || options.LooseTypes {
+		if ta, tb, ok := d.times(a, b); ok {
+			return ta.Truncate(options.TimePrecision).Equal(tb.Truncate(options.TimePrecision))
+		}
+	}
+	x, xok := d.number(a)
+	y, yok := d.number(b)
+	if xok && yok && (options.LooseTypes || reflect.TypeOf(a) == reflect.TypeOf(b)) {
+		diff := math.Abs(x - y)
+		return x == y || diff <= options.Epsilon || diff <= options.RelativeTolerance*math.Max(math.Abs(x), math.Abs(y))
+	}
+	if options.LooseTypes && a != nil && b != nil {
+		return ToString(a) == ToString(b)
+	}
+	return false
+}
+
+func (d *differ) number(v any) (float64, bool) {
+	if s, ok := v.(string); ok && d.options.LooseTypes {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return jpNumber(v)
+}
+
+// times returns a and b as times when both are, or with LooseTypes when one is a time and the other parses as one.
+func (d *differ) times(a any, b any) (time.Time, time.Time, bool) {
+	ta, aok := a.(time.Time)
+	tb, bok := b.(time.Time)
+	if d.options.LooseTypes && aok != bok {
+		var err error
+		if aok {
+			tb, err = ToTimeE(b)
+		} else {
+			ta, err = ToTimeE(a)
+		}
+		return ta, tb, err == nil
+	}
+	return ta, tb, aok && bok
+}
+
+// Reverse returns the diff that undoes d.
+func (d ObjectDiff) Reverse() ObjectDiff {
+	ret := ObjectDiff{Same: d.Same, Added: d.Deleted, Deleted: d.Added}
+	if d.Modified != nil {
+		ret.Modified = make(map[string]FieldDiff, len(d.Modified))
+		for k, fd := range d.Modified {
+			ret.Modified[k] = FieldDiff{Old: fd.New, New: fd.Old}
+		}
+	}
+	if d.Arrays == nil {
+		return ret
+	}
+
+	ret.Arrays = make(map[string]ArrayDiff, len(d.Arrays))
+	for k, ad := range d.Arrays {
+		ret.Arrays[k] = ad.reverse()
+	}
+	// changes within matched elements are keyed by their index in the new array, which becomes their index in the old
+	// one; remap the innermost arrays first while the keys of the outer ones are still unchanged
+	arrays := parseDiffKeys(d.Arrays)
+	for i := len(arrays) - 1; i >= 0; i-- {
+		sources, err := d.Arrays[arrays[i].key].sources()
+		if err != nil {
+			continue
+		}
+		remap := func(key string) string {
+			return remapIndex(key, arrays[i].parts, sources)
+		}
+		ret.Added = remapKeys(ret.Added, remap)
+		ret.Deleted = remapKeys(ret.Deleted, remap)
+		ret.Modified = remapKeys(ret.Modified, remap)
+		ret.Arrays = remapKeys(ret.Arrays, remap)
+	}
+	return ret
+}
+
+func (ad ArrayDiff) reverse() ArrayDiff {
+	ret := ArrayDiff{OldLength: ad.NewLength, NewLength: ad.OldLength, Changes: make([]ArrayChange, len(ad.Changes))}
+	for i, c := range ad.Changes {
+		c.From, c.To = c.To, c.From
+		switch c.Op {
+		case ArrayInsert:
+			c.Op = ArrayDelete
+		case ArrayDelete:
+			c.Op = ArrayInsert
+		}
+		ret.Changes[i] = c
+	}
+	return ret
+}
+
+// sources returns, for each index of the new array, the index of the element in the old array it comes from, or -1
+// for an inserted element.
+func (ad ArrayDiff) sources() ([]int, error) {
+	const unset = -2
+	sources := make([]int, ad.NewLength)
+	for i := range sources {
+		sources[i] = unset
+	}
+	used := make([]bool, ad.OldLength)
+	for _, c := range ad.Changes {
+		if c.Op != ArrayInsert && (c.From < 0 || c.From >= ad.OldLength || used[c.From]) {
+			return nil, ErrConflict
+		}
+		if c.Op != ArrayDelete && (c.To < 0 || c.To >= ad.NewLength || sources[c.To] != unset) {
+			return nil, ErrConflict
+		}
+		switch c.Op {
+		case ArrayInsert:
+			sources[c.To] = -1
+		case ArrayDelete:
+			used[c.From] = true
+		case ArrayMove:
+			sources[c.To] = c.From
+			used[c.From] = true
+		default:
+			return nil, ErrConflict
+		}
+	}
+	next := 0
+	for j := range sources {
+		if sources[j] != unset {
+			continue
+		}
+		for next < ad.OldLength && used[next] {
+			next++
+		}
+		if next == ad.OldLength {
+			return nil, ErrConflict
+		}
+		sources[j] = next
+		next++
+	}
+	for ; next < ad.OldLength; next++ {
+		if !used[next] {
+			return nil, ErrConflict
+		}
+	}
+	return sources, nil
+}
+
+// rebuild applies the array diff to the old array rv, checking that deleted elements still hold their old value.
+func (ad ArrayDiff) rebuild(rv reflect.Value) (any, bool) {
+	sources, err := ad.sources()
+	if err != nil || rv.Len() != ad.OldLength {
+		return nil, false
+	}
+	inserted := make(map[int]any)
+	for _, c := range ad.Changes {
+		switch c.Op {
+		case ArrayInsert:
+			inserted[c.To] = c.Value
+		case ArrayDelete:
+			if !jpEqual(rv.Index(c.From).Interface(), true, c.Value, true) {
+				return nil, false
+			}
+		}
+	}
+	ret := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), ad.NewLength, ad.NewLength)
+	var result any = ret.Interface()
+	for j, src := range sources {
+		value := inserted[j]
+		if src >= 0 {
+			value = rv.Index(src).Interface()
+		}
+		result = setIndex(reflect.ValueOf(result), j, value)
+	}
+	return result, true
+}
+
+// remapIndex rewrites the index following prefix in key through sources.
+func remapIndex(key string, prefix []pathPart, sources []int) string {
+	parts, err := splitPathDelim(key, "/")
+	if err != nil || len(parts) <= len(prefix) || comparePaths(parts[:len(prefix)], prefix) != 0 {
+		return key
+	}
+	part := &parts[len(prefix)]
+	if part.kind != partIndex || part.index < 0 || part.index >= len(sources) || sources[part.index] < 0 {
+		return key
+	}
+	part.index = sources[part.index]
+	return formatPath(parts, "/")
+}
+
+func remapKeys[V any, M ~map[string]V](m M, remap func(string) string) M {
+	if m == nil {
+		return nil
+	}
+	ret := make(M, len(m))
+	for k, v := range m {
+		ret[remap(k)] = v
+	}
+	return ret
+}
+
+type diffKey struct {
+	key   string
+	parts []pathPart
+	value any
+}
+
+// parseDiffKeys parses the "/" delimited keys of a diff, ordered so that a path comes before the paths below it and
+// array indexes are in increasing order.  Keys that do not parse are skipped.
+func parseDiffKeys[V any, M ~map[string]V](m M) []diffKey {
+	var keys []diffKey
+	for k, v := range m {
+		if parts, err := splitPathDelim(k, "/"); err == nil {
+			keys = append(keys, diffKey{key: k, parts: parts, value: v})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return comparePaths(keys[i].parts, keys[j].parts) < 0
+	})
+	return keys
+}
+
+// Apply turns the object the diff was made from into the one it was made to.  Every field is checked: an added field
+// must be missing, a modified or deleted field must hold its old value and an array with recorded changes must have
+// its old length and deleted elements.  If anything does not match, nothing is changed and a *ConflictError is
+// returned.  Fields that already hold the result are accepted, so applying a diff without array changes twice is
+// harmless.  Objects and arrays left empty by deletions are removed, unless the diff adds them back as empty values.
+func (d ObjectDiff) Apply(o Object) error {
+	if o == nil {
+		return ErrConflict
+	}
+	work := o.Clone()
+	var conflicts []DiffConflict
+	equal := func(a, b any) bool {
+		return jpEqual(a, true, b, true)
+	}
+
+	// outer arrays first, as the keys of nested ones use the indexes of the rebuilt outer arrays
+	for _, c := range parseDiffKeys(d.Arrays) {
+		current, found := getParts(work, c.parts)
+		if rv := reflect.ValueOf(current); found && isList(rv) {
+			if rebuilt, ok := d.Arrays[c.key].rebuild(rv); ok {
+				if _, err := setParts(work, c.parts, rebuilt); err == nil {
+					continue
+				}
+			}
+		}
+		conflicts = append(conflicts, DiffConflict{Path: c.key, Actual: current, Found: found})
+	}
+
+	// deletions first, from the highest array index down so earlier deletions do not shift later ones, which also
+	// clears the way for a value replaced by an object or the other way around
+	deleted := parseDiffKeys(d.Deleted)
+	for i := len(deleted) - 1; i >= 0; i-- {
+		c := deleted[i]
+		actual, found := getParts(work, c.parts)
+		if !found {
+			continue
+		}
+		if !equal(actual, c.value) {
+			conflicts = append(conflicts, DiffConflict{Path: c.key, Expected: c.value, Actual: actual, Found: found})
+			continue
+		}
+		deleteParts(work, c.parts)
+		for n := len(c.parts) - 1; n > 0; n-- {
+			if parent, _ := getParts(work, c.parts[:n]); !isEmptyContainer(parent) {
+				break
+			}
+			deleteParts(work, c.parts[:n])
+		}
+	}
+
+	for _, c := range parseDiffKeys(d.Modified) {
+		fd := c.value.(FieldDiff)
+		if actual, found := getParts(work, c.parts); !found || !equal(actual, fd.Old) && !equal(actual, fd.New) {
+			conflicts = append(conflicts, DiffConflict{Path: c.key, Expected: fd.Old, Actual: actual, Found: found})
+			continue
+		}
+		setParts(work, c.parts, fd.New)
+	}
+
+	for _, c := range parseDiffKeys(d.Added) {
+		if actual, found := getParts(work, c.parts); found && !equal(actual, c.value) {
+			conflicts = append(conflicts, DiffConflict{Path: c.key, Actual: actual, Found: found})
+			continue
+		}
+		if _, err := setParts(work, c.parts, c.value); err != nil {
+			conflicts = append(conflicts, DiffConflict{Path: c.key})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Slice(conflicts, func(i, j int) bool {
+			return conflicts[i].Path < conflicts[j].Path
+		})
+		return &ConflictError{Conflicts: conflicts}
+	}
+	for k := range o {
+		delete(o, k)
+	}
+	for k, v := range work {
+		o[k] = v
+	}
+	return nil
+}
+
+// comparePaths orders paths part by part, keys before indexes and indexes numerically.
+func comparePaths(a []pathPart, b []pathPart) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x.kind != y.kind:
+			return int(x.kind) - int(y.kind)
+		case x.kind == partIndex && x.index != y.index:
+			return x.index - y.index
+		case x.key < y.key:
+			return -1
+		case x.key > y.key:
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func isEmptyContainer(v any) bool {
+	if m, ok := mapOf(v); ok {
+		return len(m) == 0
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Len() == 0
+}
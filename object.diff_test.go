@@ -0,0 +1,185 @@
+package tox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffApply(t *testing.T) {
+	old := Object{
+		"name":   "dev",
+		"gone":   Object{"a": 1},
+		"config": Object{"rate": 10, "a/b": "x", "nested": Object{"deep": true}},
+		"list":   []any{1, 2, 3},
+		"keep":   []any{Object{"v": 1}},
+	}
+	updated := Object{
+		"name":   "dev2",
+		"config": Object{"rate": 20, "a/b": "y", "nested": Object{"deep": true, "new": "n"}},
+		"list":   []any{1},
+		"keep":   []any{map[string]any{"v": 2}},
+		"added":  Object{"x": nil},
+	}
+
+	diff := old.Diff(updated)
+	o := old.Clone()
+	assert.NoError(t, diff.Apply(o))
+	assert.True(t, o.Diff(updated).Same, o.JsonString(false))
+	assert.NoError(t, diff.Apply(o))
+
+	assert.NoError(t, diff.Reverse().Apply(o))
+	assert.True(t, o.Diff(old).Same, o.JsonString(false))
+	assert.Equal(t, diff, diff.Reverse().Reverse())
+
+	grown := Object{"list": []any{1, 2, 3, 4, 5}}
+	o = Object{"list": []any{1}}
+	assert.NoError(t, o.Diff(grown).Apply(o))
+	assert.Equal(t, grown, o)
+
+	o = old.Clone()
+	o.Set("config.rate", 15)
+	o.Set("added.x", 1)
+	o.Delete("gone.a")
+	err := diff.Apply(o)
+	assert.ErrorIs(t, err, ErrConflict)
+	var ce *ConflictError
+	assert.ErrorAs(t, err, &ce)
+	assert.Equal(t, []DiffConflict{
+		{Path: "added/x", Actual: 1, Found: true},
+		{Path: "config/rate", Expected: 10, Actual: 15, Found: true},
+	}, ce.Conflicts)
+	assert.Equal(t, 15, o.Get("config.rate"))
+	assert.Equal(t, "dev", o.Get("name"))
+
+	// an added element whose array has since become an Object
+	o = Object{"list": Object{"a": 1}}
+	err = Object{"list": []any{0}}.Diff(Object{"list": []any{0, 1}}).Apply(o)
+	assert.ErrorAs(t, err, &ce)
+	assert.Equal(t, []DiffConflict{{Path: "list[1]"}}, ce.Conflicts)
+	assert.Equal(t, Object{"list": Object{"a": 1}}, o)
+}
+
+func TestDiffWith(t *testing.T) {
+	assert.Equal(t, ObjectDiff{Same: false}, Object{"a": 1}.DiffWith(nil, DiffOptions{}))
+	assert.Equal(t, ObjectDiff{Same: false}, Object(nil).DiffWith(Object{"a": 1}, DiffOptions{}))
+	assert.Equal(t, ObjectDiff{Same: true}, Object(nil).DiffWith(nil, DiffOptions{}))
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	a := Object{
+		"temp":    21.5,
+		"count":   1,
+		"label":   "1",
+		"seen":    now,
+		"meta":    Object{"updated": "x"},
+		"sensors": []any{Object{"id": "s1", "ts": 1}, Object{"id": "s2", "ts": 2}},
+	}
+	b := Object{
+		"temp":    21.50001,
+		"count":   1.0,
+		"label":   1,
+		"seen":    now.Add(300 * time.Millisecond),
+		"meta":    Object{"updated": "y"},
+		"sensors": []any{Object{"id": "s1", "ts": 9}, Object{"id": "s2", "ts": 8}},
+	}
+
+	diff := a.DiffWith(b, DiffOptions{})
+	assert.Equal(t, a.Diff(b), diff)
+	assert.Len(t, diff.Modified, 7)
+
+	diff = a.DiffWith(b, DiffOptions{
+		Ignore:        []string{"meta", "sensors[*].ts"},
+		Epsilon:       0.001,
+		LooseTypes:    true,
+		TimePrecision: time.Second,
+	})
+	assert.True(t, diff.Same, diff)
+
+	assert.Len(t, a.DiffWith(b, DiffOptions{RelativeTolerance: 1e-3}).Modified, 6)
+	assert.Len(t, a.DiffWith(b, DiffOptions{Ignore: []string{"..ts", "..updated"}}).Modified, 4)
+}
+
+func TestDiffWithArrays(t *testing.T) {
+	a := Object{"items": []any{
+		Object{"id": 1, "v": "a"},
+		Object{"id": 2, "v": "b"},
+		Object{"id": 3, "v": "c"},
+		Object{"id": 4, "v": "d"},
+	}}
+	b := Object{"items": []any{
+		Object{"id": 0, "v": "new"},
+		Object{"id": 1, "v": "a"},
+		Object{"id": 4, "v": "d"},
+		Object{"id": 3, "v": "C"},
+	}}
+
+	assert.Len(t, a.Diff(b).Modified, 8)
+
+	diff := a.DiffWith(b, DiffOptions{ArrayKey: "id"})
+	assert.Equal(t, map[string]FieldDiff{"items[3]/v": {Old: "c", New: "C"}}, diff.Modified)
+	assert.Equal(t, map[string]ArrayDiff{"items": {OldLength: 4, NewLength: 4, Changes: []ArrayChange{
+		{Op: ArrayDelete, From: 1, To: -1, Value: Object{"id": 2, "v": "b"}},
+		{Op: ArrayMove, From: 2, To: 3},
+		{Op: ArrayInsert, From: -1, To: 0, Value: Object{"id": 0, "v": "new"}},
+	}}}, diff.Arrays)
+
+	o := a.Clone()
+	assert.NoError(t, diff.Apply(o))
+	assert.Equal(t, b, o)
+	assert.ErrorIs(t, diff.Apply(o), ErrConflict)
+	assert.NoError(t, diff.Reverse().Apply(o))
+	assert.Equal(t, a, o)
+
+	diff = a.DiffWith(b, DiffOptions{ArrayLCS: true})
+	assert.Nil(t, diff.Modified)
+	assert.Len(t, diff.Arrays["items"].Changes, 4)
+	o = a.Clone()
+	assert.NoError(t, diff.Apply(o))
+	assert.Equal(t, b, o)
+	assert.NoError(t, diff.Reverse().Apply(o))
+	assert.Equal(t, a, o)
+
+	nested := func(v ...string) Object {
+		var groups []any
+		for i, s := range v {
+			groups = append(groups, Object{"id": i, "tags": []any{Object{"id": s, "n": len(v)}}})
+		}
+		return Object{"groups": groups}
+	}
+	x, y := nested("a", "b", "c"), nested("c", "a")
+	y["groups"] = []any{y["groups"].([]any)[1], Object{"id": 9}, y["groups"].([]any)[0]}
+	diff = x.DiffWith(y, DiffOptions{ArrayKey: "id"})
+	o = x.Clone()
+	assert.NoError(t, diff.Apply(o))
+	assert.True(t, o.Diff(y).Same)
+	assert.NoError(t, diff.Reverse().Apply(o))
+	assert.True(t, o.Diff(x).Same)
+}
+
+func TestDiffApplyEmptyContainers(t *testing.T) {
+	for _, c := range []struct{ from, to Object }{
+		{Object{"a": Object{}}, Object{"a": 1}},
+		{Object{"a": []any{}}, Object{"a": 1}},
+		{Object{"a": 1}, Object{"a": Object{}}},
+		{Object{"a": 1}, Object{"a": []any{}}},
+		{Object{}, Object{"a": Object{}, "b": []any{}}},
+		{Object{"a": Object{}, "b": []any{}}, Object{}},
+		{Object{"a": Object{"x": 1}}, Object{"a": Object{}}},
+		{Object{"a": []any{1, 2}}, Object{"a": []any{}}},
+		{Object{"a": Object{"x": 1}}, Object{}},
+		{Object{"a": []any{Object{"x": 1}}}, Object{"a": []any{Object{}}}},
+		{Object{"a": Object{}}, Object{"a": Object{"x": []any{}}}},
+		{Object{"a": []any{}}, Object{"a": []any{1}}},
+	} {
+		diff := c.from.Diff(c.to)
+		assert.False(t, diff.Same, c.from)
+		o := c.from.Clone()
+		assert.NoError(t, diff.Apply(o), c.from)
+		assert.Equal(t, c.to, o, c.from)
+
+		back := c.to.Clone()
+		assert.NoError(t, diff.Reverse().Apply(back), c.to)
+		assert.Equal(t, c.from, back, c.to)
+	}
+}
@@ -1,13 +1,11 @@
 package tox
 
 import (
-	"fmt"
 	"math"
 	"reflect"
 	"strings"
 	"time"
 
-	"dario.cat/mergo"
 	"github.com/goccy/go-json"
 )
 
@@ -53,60 +51,18 @@ func (o Object) ToStruct(target any) {
 	_ = json.Unmarshal(b, target)
 }
 
-func (o Object) Equals(other Object) bool {
-	if (o == nil && other != nil) || (o != nil && other == nil) {
-		return false
-	} else if o == nil {
-		return true
-	}
-	if fmt.Sprintf("%v", o) == fmt.Sprintf("%v", other) {
-		return true
-	}
-	return false
-}
-
-func countFields(x any) int {
+// FieldCount returns the number of leaf values in the object, counting through nested Objects and arrays.
+func (o Object) FieldCount() int {
 	count := 0
-	switch tx := x.(type) {
-	case []any:
-		for _, v := range tx {
-			count += countFields(v)
+	o.Walk(func(path Path, value any) WalkAction {
+		if _, container := containerLen(value); !container {
+			count++
 		}
-	case map[string]any:
-		for _, v := range tx {
-			count += countFields(v)
-		}
-	default:
-		count++
-	}
+		return WalkContinue
+	})
 	return count
 }
 
-func (o Object) FieldCount() int {
-	if o == nil {
-		return 0
-	}
-	return countFields(o)
-}
-
-func keyIndex(key string) (string, int) {
-	lb := strings.Index(key, "[")
-	rb := strings.Index(key, "]")
-	var sk string
-	var si int
-	if lb > 0 {
-		sk = key[:lb]
-		if rb == -1 {
-			si = ToInt(key[lb+1:])
-		} else {
-			si = ToInt(key[lb+1 : rb])
-		}
-		return sk, si
-	} else {
-		return key, -1
-	}
-}
-
 func (o Object) Move(from string, to string) {
 	if o == nil {
 		return
@@ -115,8 +71,14 @@ func (o Object) Move(from string, to string) {
 		return
 	}
 	f := o.Get(from)
-	if f != nil {
-		o.Set(to, f)
+	if f == nil {
+		return
+	}
+	parts, err := splitPath(to)
+	if err != nil {
+		return
+	}
+	if _, err := setParts(o, parts, f); err == nil {
 		o.Delete(from)
 	}
 }
@@ -125,35 +87,30 @@ func (o Object) Delete(key string) {
 	if o == nil {
 		return
 	}
-	idx := strings.LastIndex(key, ".")
-	if idx == -1 {
-		delete(o, key)
-	} else {
-		parent := o.GetObject(key[:idx])
-		if parent != nil {
-			delete(parent, key[idx+1:])
-		}
+	parts, err := splitPath(key)
+	if err != nil {
+		return
 	}
+	deleteParts(o, parts)
 }
 
 func (o Object) DeletePrefix(prefix string) {
 	if o == nil {
 		return
 	}
-	idx := strings.LastIndex(prefix, ".")
-	if idx == -1 {
-		for k := range o {
-			if strings.HasPrefix(k, prefix) {
-				delete(o, k)
-			}
-		}
-	} else {
-		parent := o.GetObject(prefix[:idx])
-		if parent != nil {
-			for k := range parent {
-				if strings.HasPrefix(k, prefix) {
-					delete(o, k)
-				}
+	parts, err := splitPath(prefix)
+	if err != nil {
+		return
+	}
+	parent, found := getParts(o, parts[:len(parts)-1])
+	if !found {
+		return
+	}
+	last := parts[len(parts)-1]
+	if m, ok := mapOf(parent); ok && last.kind == partKey {
+		for k := range m {
+			if strings.HasPrefix(k, last.key) {
+				delete(m, k)
 			}
 		}
 	}
@@ -169,117 +126,18 @@ func (o Object) Exists(key string) bool {
 	return false
 }
 
+// Get returns the value at key, which is a dotted path like "a.b[2].c", see JoinPath for keys that contain dots or
+// brackets.  It returns nil if there is no such value.
 func (o Object) Get(key string) any {
 	if o == nil {
 		return nil
 	}
-	parts := strings.Split(key, ".")
-	if len(parts) == 1 {
-		k, i := keyIndex(key)
-		if field, found := o[k]; found {
-			if i == -1 {
-				return field
-			} else {
-				if fa, ok := field.([]any); ok {
-					if i < 0 || i >= len(fa) {
-						return nil
-					}
-					return fa[i]
-				} else {
-					return nil
-				}
-			}
-		} else {
-			return nil
-		}
-	} else {
-		mi := o
-		for partIdx, part := range parts {
-			k, i := keyIndex(part)
-			if p, found := mi[k]; found {
-				if i == -1 {
-					if pi, ok := p.(map[string]any); ok && partIdx != len(parts)-1 {
-						mi = pi
-					} else if pi, ok := p.(Object); ok && partIdx != len(parts)-1 {
-						mi = pi
-					} else if partIdx == len(parts)-1 {
-						return p
-					} else {
-						return nil
-					}
-				} else {
-					switch pa := p.(type) {
-					case []int:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []int64:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []float32:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []float64:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []bool:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []string:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						return pa[i]
-					case []map[string]any:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						if partIdx == len(parts)-1 {
-							return pa[i]
-						}
-						mi = pa[i]
-					case []Object:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						if partIdx == len(parts)-1 {
-							return pa[i]
-						}
-						mi = pa[i]
-					case []any:
-						if i < 0 || i >= len(pa) {
-							return nil
-						}
-						if partIdx == len(parts)-1 {
-							return pa[i]
-						}
-						// Check if the array element is a map or Object and continue processing
-						if mapVal, ok := pa[i].(map[string]any); ok {
-							mi = mapVal
-						} else if objVal, ok := pa[i].(Object); ok {
-							mi = objVal
-						} else {
-							return pa[i]
-						}
-					default:
-						return nil
-					}
-				}
-			} else {
-				return nil
-			}
-		}
+	parts, err := splitPath(key)
+	if err != nil {
+		return nil
 	}
-	return nil
+	v, _ := getParts(o, parts)
+	return v
 }
 
 func (o Object) GetObjectArray(key string) []Object {
@@ -463,6 +321,17 @@ func (o Object) GetTimePtr(key string, def time.Time) *time.Time {
 	}
 }
 
+func (o Object) GetDuration(key string, def time.Duration) time.Duration {
+	if o == nil {
+		return def
+	}
+	if field := o.Get(key); field != nil {
+		return ToDuration(field)
+	} else {
+		return def
+	}
+}
+
 func (o Object) GetBytes(key string, def []byte) []byte {
 	if o == nil {
 		return def
@@ -603,79 +472,30 @@ func (o Object) Set(key string, value any) {
 	} else if value == Null {
 		value = nil
 	}
-	parts := strings.Split(key, ".")
-	if len(parts) == 1 {
-		o[key] = value
-	} else {
-		mi := o
-		for partIdx, part := range parts {
-			if p, found := mi[part]; found {
-				if pi, ok := p.(map[string]any); ok && partIdx != len(parts)-1 {
-					mi = pi
-				} else if pi, ok := p.(Object); ok && partIdx != len(parts)-1 {
-					mi = pi
-				} else {
-					mi[part] = value
-				}
-			} else {
-				if partIdx != len(parts)-1 {
-					pi := Object{}
-					mi[part] = pi
-					mi = pi
-				} else {
-					mi[part] = value
-				}
-			}
-		}
+	parts, err := splitPath(key)
+	if err != nil {
+		return
 	}
-	return
+	setParts(o, parts, value)
 }
 
+// Merge merges other into the object, overwriting existing values with those of other, even empty ones such as
+// false, 0 or nil, and replacing arrays.
 func (o Object) Merge(other Object) {
-	_ = mergo.Merge(&o, other, mergo.WithOverride)
+	o.MergeWith(other, MergeOptions{OverwriteWithEmpty: true})
 }
+
+// MergeMissing merges other into the object, only setting values that are missing or empty.
 func (o Object) MergeMissing(other Object) {
-	_ = mergo.Merge(&o, other)
+	o.MergeWith(other, MergeOptions{KeepExisting: true})
 }
 
+// Flatten flattens nested Objects and arrays into a single Object whose keys are the paths to each value, such as
+// "a.b[0].c" with a "." delim, keeping empty Objects and arrays as values.  Keys are joined as they are, so a key
+// that contains delim or ends in [n] reads back as a nested path and Unflatten does not restore it; FlattenWith with
+// Escape set makes such keys unambiguous.  Use Unflatten to reverse it.
 func (o Object) Flatten(delim string) Object {
-	output := make(map[string]any)
-
-	var flatten func(map[string]any, string)
-	flatten = func(m map[string]any, parentKey string) {
-		for k, v := range m {
-			key := parentKey + k
-			if parentKey != "" {
-				key = parentKey + delim + k
-			}
-
-			switch value := v.(type) {
-			case map[string]any:
-				flatten(value, key)
-			case Object:
-				flatten(value, key)
-			default:
-				fieldVal := reflect.ValueOf(value)
-				if fieldVal.Kind() == reflect.Array || fieldVal.Kind() == reflect.Slice {
-					for i := 0; i < fieldVal.Len(); i++ {
-						vv := fieldVal.Index(i).Interface()
-						switch vvv := vv.(type) {
-						case map[string]any:
-							flatten(vvv, key+fmt.Sprintf("[%d]", i))
-						default:
-							output[key+fmt.Sprintf("[%d]", i)] = vvv
-						}
-					}
-				} else {
-					output[key] = value
-				}
-			}
-		}
-	}
-
-	flatten(o, "")
-
-	return output
+	return o.FlattenWith(FlattenOptions{Delim: delim, KeepEmpty: true})
 }
 
 type FieldDiff struct {
@@ -687,6 +507,7 @@ type ObjectDiff struct {
 	Added    Object               `json:"added,omitempty"    bson:"added,omitempty"`
 	Modified map[string]FieldDiff `json:"modified,omitempty" bson:"modified,omitempty"`
 	Deleted  Object               `json:"deleted,omitempty"  bson:"deleted,omitempty"`
+	Arrays   map[string]ArrayDiff `json:"arrays,omitempty"   bson:"arrays,omitempty"`
 }
 
 func (o Object) JsonString(pretty bool) string {
@@ -715,37 +536,30 @@ func (o Object) JsonBytes(pretty bool) []byte {
 	}
 }
 
+// Diff compares the object to other, listing the leaves that were added, modified or deleted.  Keys are paths
+// delimited by "/", such as "a/b[0]/c", with any "/", bracket or backslash in a key escaped as JoinPath does, so
+// "a/b" is written as "a\/b".  Arrays that are the value of a key are compared element by element, while arrays
+// within arrays are compared as single values, and empty Objects and arrays are values of their own.  The keys are
+// those of FlattenWith with Delim "/", Escape and KeepEmpty, except for arrays within arrays.
 func (o Object) Diff(other Object) ObjectDiff {
-	if o == nil && other == nil {
-		return ObjectDiff{Same: true}
-	} else if o == nil || other == nil {
-		return ObjectDiff{Same: false}
-	}
-	origf := o.Flatten("/")
-	otherf := other.Flatten("/")
-
-	diff := diffMaps(origf, otherf)
-	if diff.Added == nil && diff.Modified == nil && diff.Deleted == nil {
-		diff.Same = true
-	} else {
-		diff.Same = false
-	}
-
-	return diff
+	return o.DiffWith(other, DiffOptions{})
 }
 
+// RemoveNaN deletes NaN values from the object, or sets them to zero inside arrays, converting any structs first.
 func (o Object) RemoveNaN() {
 	if o == nil {
 		return
 	}
-	toBeDeleted := []string{}
 	o.ConvertStructs()
-	removeNaN(o, "", &toBeDeleted)
-	if len(toBeDeleted) > 0 {
-		for _, key := range toBeDeleted {
-			o.Delete(key)
+	o.Walk(func(path Path, value any) WalkAction {
+		if !isNan(value) {
+			return WalkContinue
 		}
-	}
+		if path[len(path)-1].IsIndex {
+			return WalkReplace(float64(0))
+		}
+		return WalkDelete
+	})
 }
 
 func (o Object) ConvertStructs() {
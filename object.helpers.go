@@ -54,6 +54,14 @@ func structToAnything(input any) any {
 
 	v := reflect.ValueOf(input)
 	t := reflect.TypeOf(input)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+
+	// Registered converters, this is how mongodb ObjectIDs become hex strings
+	if cv, found, err := convertRegistered(input, AnyType); found && err == nil {
+		return cv
+	}
 
 	// Handle pointers
 	for v.Kind() == reflect.Ptr {
@@ -62,6 +70,9 @@ func structToAnything(input any) any {
 		}
 		v = v.Elem()
 		t = v.Type()
+		if cv, found, err := convertRegistered(v.Interface(), AnyType); found && err == nil {
+			return cv
+		}
 	}
 
 	// Special case: time.Time should be returned as is
@@ -69,22 +80,6 @@ func structToAnything(input any) any {
 		return v.Interface()
 	}
 
-	// Handle types with Hex() method that look like ObjectID
-	if strings.Contains(t.String(), "ObjectID") {
-		if m, ok := v.Interface().(interface{ Hex() string }); ok {
-			return m.Hex()
-		}
-	}
-
-	// Handle pointers to types with Hex() method that look like ObjectID
-	if t.Kind() == reflect.Ptr && strings.Contains(t.Elem().String(), "ObjectID") {
-		if !v.IsNil() {
-			if m, ok := v.Interface().(interface{ Hex() string }); ok {
-				return m.Hex()
-			}
-		}
-	}
-
 	// Special case: []byte should be returned as is
 	if b, ok := v.Interface().([]byte); ok {
 		return b
@@ -146,77 +141,7 @@ func structToAnything(input any) any {
 	}
 }
 
-func removeNaN(a any, parent string, toBeDeleted *[]string) {
-	if len(parent) != 0 {
-		parent += "."
-	}
-	v := reflect.ValueOf(a)
-	switch v.Kind() {
-	case reflect.Map:
-		for _, key := range v.MapKeys() {
-			originalValue := v.MapIndex(key)
-			if !isNan(originalValue.Interface()) {
-				removeNaN(originalValue.Interface(), parent+key.String(), toBeDeleted)
-			} else {
-				*toBeDeleted = append(*toBeDeleted, parent+key.String())
-			}
-		}
-	case reflect.Slice:
-		for i := 0; i < v.Len(); i++ {
-			originalValue := v.Index(i)
-			if isNan(originalValue.Interface()) {
-				v.Index(i).Set(reflect.ValueOf(float64(0.0)))
-			}
-		}
-
-		//case reflect.Struct:
-		//	spew.Dump("struct", v.Interface())
-		//	panic("no structs allowed in removeNaN")
-		//case reflect.Ptr:
-		//	panic("no struct pointers allowed in removeNaN")
-	default:
-		// do nothing
-	}
-}
-
 func isNan(a any) bool {
 	v := reflect.ValueOf(a)
 	return v.Kind() == reflect.Float64 && math.IsNaN(v.Float())
 }
-
-func diffMaps(oldMap, newMap map[string]any) ObjectDiff {
-	result := ObjectDiff{
-		Added:    Object{},
-		Modified: make(map[string]FieldDiff),
-		Deleted:  Object{},
-	}
-
-	// Check for added and modified key-value pairs
-	for key, newValue := range newMap {
-		if oldValue, exists := oldMap[key]; exists {
-			if !reflect.DeepEqual(oldValue, newValue) {
-				result.Modified[key] = FieldDiff{Old: oldValue, New: newValue}
-			}
-		} else {
-			result.Added[key] = newValue
-		}
-	}
-
-	// Check for deleted key-value pairs
-	for key, oldValue := range oldMap {
-		if _, exists := newMap[key]; !exists {
-			result.Deleted[key] = oldValue
-		}
-	}
-	if len(result.Added) == 0 {
-		result.Added = nil
-	}
-	if len(result.Deleted) == 0 {
-		result.Deleted = nil
-	}
-	if len(result.Modified) == 0 {
-		result.Modified = nil
-	}
-
-	return result
-}
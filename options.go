@@ -1,7 +1,38 @@
 package tox
 
+import "time"
+
 type Options struct {
 	EmptyStringAsNull bool
 	FloatPrecision    int
 	FloatToInt        bool
+	// StringMethods is the order ToStringOpts tries the string methods a type implements in, defaults to
+	// MethodTextMarshaler, MethodStringer, MethodJSONMarshaler.
+	StringMethods []StringMethod
+	// Time controls how strings and numbers are converted to time.Time, defaults to the options set with
+	// SetDefaultTimeOptions.
+	Time *TimeOptions
+	// DurationUnit is the unit of numbers converted by ToDurationOpts, defaults to time.Second.
+	DurationUnit time.Duration
+}
+
+// StringMethod identifies an interface a type can implement to control how it is converted to a string.
+type StringMethod int
+
+const (
+	// MethodTextMarshaler is encoding.TextMarshaler.
+	MethodTextMarshaler StringMethod = iota + 1
+	// MethodStringer is fmt.Stringer.
+	MethodStringer
+	// MethodJSONMarshaler is json.Marshaler, a JSON string result is unquoted.
+	MethodJSONMarshaler
+)
+
+var defaultStringMethods = []StringMethod{MethodTextMarshaler, MethodStringer, MethodJSONMarshaler}
+
+func (o *Options) stringMethods() []StringMethod {
+	if o == nil || o.StringMethods == nil {
+		return defaultStringMethods
+	}
+	return o.StringMethods
 }
@@ -0,0 +1,274 @@
+package tox
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/goccy/go-json"
+)
+
+// JSON Patch operations.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+var (
+	// ErrPatchPath is returned when a patch operation addresses a location that does not exist.
+	ErrPatchPath = errors.New("patch path does not exist")
+	// ErrPatchTest is returned when a test operation does not match.
+	ErrPatchTest = errors.New("patch test failed")
+	// ErrPatchOp is returned for an unknown or malformed patch operation.
+	ErrPatchOp = errors.New("invalid patch operation")
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation.  Path and From are JSON Pointers.
+type PatchOp struct {
+	Op    string `json:"op"             bson:"op"`
+	Path  string `json:"path"           bson:"path"`
+	From  string `json:"from,omitempty" bson:"from,omitempty"`
+	Value any    `json:"value"          bson:"value,omitempty"`
+}
+
+// MarshalJSON writes value only for the operations that take one, so a null value is kept for add, replace and test.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	type patchOp PatchOp
+	if op.Op == PatchAdd || op.Op == PatchReplace || op.Op == PatchTest {
+		return json.Marshal(patchOp(op))
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}{op.Op, op.Path, op.From})
+}
+
+// PatchError reports which operation of a patch failed.
+type PatchError struct {
+	Index int
+	Op    PatchOp
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("tox: patch operation %d (%s %s) failed: %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch to the object.  The operations are applied to a copy, so when one of
+// them fails the object is left unchanged and a *PatchError is returned.
+func (o Object) ApplyPatch(ops []PatchOp) error {
+	if o == nil {
+		return ErrPatchPath
+	}
+	work := o.Clone()
+	for i, op := range ops {
+		var err error
+		if work, err = applyPatchOp(work, op); err != nil {
+			return &PatchError{Index: i, Op: op, Err: err}
+		}
+	}
+	for k := range o {
+		delete(o, k)
+	}
+	for k, v := range work {
+		o[k] = v
+	}
+	return nil
+}
+
+func applyPatchOp(doc Object, op PatchOp) (Object, error) {
+	parts, err := pointerParts(doc, op.Path)
+	if err != nil {
+		return doc, err
+	}
+	switch op.Op {
+	case PatchAdd:
+		return patchAdd(doc, parts, op.Value)
+	case PatchRemove:
+		return patchRemove(doc, parts)
+	case PatchReplace:
+		if len(parts) > 0 {
+			if doc, err = patchRemove(doc, parts); err != nil {
+				return doc, err
+			}
+		}
+		return patchAdd(doc, parts, op.Value)
+	case PatchMove, PatchCopy:
+		from, err := pointerParts(doc, op.From)
+		if err != nil {
+			return doc, err
+		}
+		value, found := getParts(doc, from)
+		if !found {
+			return doc, ErrPatchPath
+		}
+		if op.Op == PatchCopy {
+			value, _ = Deepcopy(value)
+		} else {
+			if len(from) < len(parts) && reflect.DeepEqual(from, parts[:len(from)]) {
+				return doc, ErrPatchOp
+			}
+			if doc, err = patchRemove(doc, from); err != nil {
+				return doc, err
+			}
+			// removing from the same array shifts the indexes of the target
+			if parts, err = pointerParts(doc, op.Path); err != nil {
+				return doc, err
+			}
+		}
+		return patchAdd(doc, parts, value)
+	case PatchTest:
+		value, found := getParts(doc, parts)
+		if !found || !jpEqual(value, true, op.Value, true) {
+			return doc, ErrPatchTest
+		}
+		return doc, nil
+	default:
+		return doc, ErrPatchOp
+	}
+}
+
+// patchAdd adds value at parts, inserting it when the parent is an array and replacing the whole document when parts
+// is empty.
+func patchAdd(doc Object, parts []pathPart, value any) (Object, error) {
+	if len(parts) == 0 {
+		m, ok := mapOf(value)
+		if !ok {
+			return doc, ErrPatchOp
+		}
+		return Object(m), nil
+	}
+	parent, found := getParts(doc, parts[:len(parts)-1])
+	if !found {
+		return doc, ErrPatchPath
+	}
+	last := parts[len(parts)-1]
+	if m, ok := mapOf(parent); ok {
+		m[last.key] = value
+		return doc, nil
+	}
+	rv := reflect.ValueOf(parent)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return doc, ErrPatchPath
+	}
+	index := rv.Len()
+	if last.kind == partIndex {
+		index = last.index
+	}
+	if index > rv.Len() {
+		return doc, ErrPatchPath
+	}
+	if _, err := setParts(doc, parts[:len(parts)-1], insertIndex(parent, index, value)); err != nil {
+		return doc, ErrPatchPath
+	}
+	return doc, nil
+}
+
+func patchRemove(doc Object, parts []pathPart) (Object, error) {
+	if len(parts) == 0 {
+		return doc, ErrPatchOp
+	}
+	if _, found := getParts(doc, parts); !found {
+		return doc, ErrPatchPath
+	}
+	deleteParts(doc, parts)
+	return doc, nil
+}
+
+// DiffPatch returns an RFC 6902 JSON Patch that turns the object into other.  Nested objects are compared key by key
+// and arrays element by element after skipping their common prefix and suffix, so a change deep in the tree becomes
+// a single replace rather than a replace of the whole branch.
+func (o Object) DiffPatch(other Object) []PatchOp {
+	if o == nil {
+		o = Object{}
+	}
+	if other == nil {
+		other = Object{}
+	}
+	return diffPatch(o, other, nil, nil)
+}
+
+func diffPatch(a any, b any, at []pathPart, ops []PatchOp) []PatchOp {
+	ptr := func(part pathPart) string {
+		p, _ := formatPointer(appendPart(at, part))
+		return p
+	}
+	value := func(v any) any {
+		ret, _ := Deepcopy(v)
+		return ret
+	}
+
+	am, aok := mapOf(a)
+	bm, bok := mapOf(b)
+	if aok && bok {
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, found := am[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			part := pathPart{kind: partKey, key: k}
+			av, inA := am[k]
+			bv, inB := bm[k]
+			switch {
+			case !inB:
+				ops = append(ops, PatchOp{Op: PatchRemove, Path: ptr(part)})
+			case !inA:
+				ops = append(ops, PatchOp{Op: PatchAdd, Path: ptr(part), Value: value(bv)})
+			default:
+				ops = diffPatch(av, bv, appendPart(at, part), ops)
+			}
+		}
+		return ops
+	}
+
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if ra.Kind() == reflect.Slice && rb.Kind() == reflect.Slice {
+		la, lb := ra.Len(), rb.Len()
+		equal := func(i, j int) bool {
+			return jpEqual(ra.Index(i).Interface(), true, rb.Index(j).Interface(), true)
+		}
+		prefix := 0
+		for prefix < la && prefix < lb && equal(prefix, prefix) {
+			prefix++
+		}
+		suffix := 0
+		for suffix < la-prefix && suffix < lb-prefix && equal(la-1-suffix, lb-1-suffix) {
+			suffix++
+		}
+		ma, mb := la-prefix-suffix, lb-prefix-suffix
+		for k := 0; k < ma && k < mb; k++ {
+			i := prefix + k
+			ops = diffPatch(ra.Index(i).Interface(), rb.Index(i).Interface(), appendPart(at, pathPart{kind: partIndex, index: i}), ops)
+		}
+		for k := mb; k < ma; k++ {
+			ops = append(ops, PatchOp{Op: PatchRemove, Path: ptr(pathPart{kind: partIndex, index: prefix + mb})})
+		}
+		for k := ma; k < mb; k++ {
+			i := prefix + k
+			ops = append(ops, PatchOp{Op: PatchAdd, Path: ptr(pathPart{kind: partIndex, index: i}), Value: value(rb.Index(i).Interface())})
+		}
+		return ops
+	}
+
+	if !jpEqual(a, true, b, true) {
+		p, _ := formatPointer(at)
+		ops = append(ops, PatchOp{Op: PatchReplace, Path: p, Value: value(b)})
+	}
+	return ops
+}
@@ -0,0 +1,105 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPatch(t *testing.T) {
+	o := Object{"foo": []any{"bar", "baz"}, "obj": Object{"a": 1}, "typed": []string{"x"}}
+	err := o.ApplyPatch([]PatchOp{
+		{Op: PatchAdd, Path: "/foo/1", Value: "qux"},
+		{Op: PatchAdd, Path: "/foo/-", Value: "end"},
+		{Op: PatchRemove, Path: "/foo/0"},
+		{Op: PatchReplace, Path: "/obj/a", Value: 2},
+		{Op: PatchAdd, Path: "/obj/b", Value: nil},
+		{Op: PatchCopy, From: "/obj", Path: "/copy"},
+		{Op: PatchMove, From: "/foo/0", Path: "/foo/-"},
+		{Op: PatchAdd, Path: "/typed/0", Value: "w"},
+		{Op: PatchTest, Path: "/obj/a", Value: 2.0},
+		{Op: PatchTest, Path: "/copy", Value: map[string]any{"a": 2, "b": nil}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Object{
+		"foo":   []any{"baz", "end", "qux"},
+		"obj":   Object{"a": 2, "b": nil},
+		"copy":  Object{"a": 2, "b": nil},
+		"typed": []string{"w", "x"},
+	}, o)
+
+	o.SetPointer("/copy/a", 3)
+	assert.Equal(t, 2, o.GetPointer("/obj/a"))
+
+	before := o.Clone()
+	for _, ops := range [][]PatchOp{
+		{{Op: PatchReplace, Path: "/obj/a", Value: 5}, {Op: PatchTest, Path: "/obj/a", Value: 6}},
+		{{Op: PatchRemove, Path: "/obj/a"}, {Op: PatchRemove, Path: "/missing"}},
+		{{Op: PatchAdd, Path: "/missing/a", Value: 1}},
+		{{Op: PatchAdd, Path: "/foo/9", Value: 1}},
+		{{Op: PatchMove, From: "/obj", Path: "/obj/child"}},
+		{{Op: "frobnicate", Path: "/obj"}},
+		{{Op: PatchReplace, Path: "/nothing", Value: 1}},
+	} {
+		err := o.ApplyPatch(ops)
+		assert.Error(t, err)
+		var pe *PatchError
+		assert.ErrorAs(t, err, &pe)
+		assert.Equal(t, before, o)
+	}
+	assert.ErrorIs(t, o.ApplyPatch([]PatchOp{{Op: PatchTest, Path: "/obj/a", Value: 6}}), ErrPatchTest)
+	assert.ErrorIs(t, o.ApplyPatch([]PatchOp{{Op: PatchRemove, Path: "/missing"}}), ErrPatchPath)
+
+	assert.NoError(t, o.ApplyPatch([]PatchOp{{Op: PatchReplace, Path: "", Value: map[string]any{"new": true}}}))
+	assert.Equal(t, Object{"new": true}, o)
+}
+
+func TestDiffPatch(t *testing.T) {
+	a := Object{
+		"name":    "dev",
+		"removed": 1,
+		"shadow":  Object{"temp": 20, "tags": []any{"a", "b", "c"}},
+		"list":    []any{1, 2, 3, 4},
+	}
+	b := Object{
+		"name":   "dev",
+		"added":  Object{"x": 1},
+		"shadow": Object{"temp": 21.5, "tags": []any{"a", "x", "b", "c"}},
+		"list":   []any{1, 4},
+	}
+
+	ops := a.DiffPatch(b)
+	assert.Equal(t, []PatchOp{
+		{Op: PatchAdd, Path: "/added", Value: Object{"x": 1}},
+		{Op: PatchRemove, Path: "/list/1"},
+		{Op: PatchRemove, Path: "/list/1"},
+		{Op: PatchRemove, Path: "/removed"},
+		{Op: PatchAdd, Path: "/shadow/tags/1", Value: "x"},
+		{Op: PatchReplace, Path: "/shadow/temp", Value: 21.5},
+	}, ops)
+
+	assert.NoError(t, a.ApplyPatch(ops))
+	assert.Equal(t, b, a)
+	assert.Empty(t, a.DiffPatch(b))
+
+	c := Object{"a": []any{Object{"v": 1}, Object{"v": 2}}}
+	d := Object{"a": []any{Object{"v": 1}, Object{"v": 3}}, "b/c~": nil}
+	ops = c.DiffPatch(d)
+	assert.Equal(t, []PatchOp{
+		{Op: PatchReplace, Path: "/a/1/v", Value: 3},
+		{Op: PatchAdd, Path: "/b~1c~0", Value: nil},
+	}, ops)
+	assert.NoError(t, c.ApplyPatch(ops))
+	assert.Equal(t, d, c)
+
+	b1, err := json.Marshal(ops)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"op":"replace","path":"/a/1/v","value":3},{"op":"add","path":"/b~1c~0","value":null}]`, string(b1))
+	b2, _ := json.Marshal(PatchOp{Op: PatchMove, From: "/a", Path: "/b"})
+	assert.JSONEq(t, `{"op":"move","from":"/a","path":"/b"}`, string(b2))
+
+	var back []PatchOp
+	assert.NoError(t, json.Unmarshal(b1, &back))
+	assert.Equal(t, PatchAdd, back[1].Op)
+}
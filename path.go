@@ -0,0 +1,451 @@
+package tox
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrPath is returned for malformed paths, and for paths that index into an Object or take a key of an array.
+var ErrPath = errors.New("invalid path")
+
+type partKind int
+
+const (
+	partKey partKind = iota
+	partIndex
+	partAppend
+	partWildcard
+	partDescend
+	partSlice
+)
+
+// pathPart is one step of a parsed path, a map key, an array index or the position after the end of an array.  Query
+// paths may also contain wildcards, recursive descent and array slices, where start and end are nil when omitted.
+type pathPart struct {
+	kind  partKind
+	key   string
+	index int
+	start *int
+	end   *int
+}
+
+// splitPath parses a dotted path.  Keys are separated by dots and may be followed by any number of [n] array indexes.
+// A backslash escapes the next character, and a key may be quoted as "a.b" or written in brackets as ["a.b"] or
+// ['a.b'], so keys that contain dots or brackets can still be addressed:
+//
+//	sensors.temp        {"sensors": {"temp": ...}}
+//	sensors\.temp       {"sensors.temp": ...}
+//	"sensors.temp"      {"sensors.temp": ...}
+//	items[2].name       {"items": [..., ..., {"name": ...}]}
+//	items[-1]           the last element of items
+//	items[]             appends to items when setting, items[-] does the same
+//	labels["a[1]"]      {"labels": {"a[1]": ...}}
+func splitPath(path string) ([]pathPart, error) {
+	return splitPathDelim(path, ".")
+}
+
+// splitPathDelim parses a path whose keys are separated by delim instead of a dot, as written by Flatten.
+func splitPathDelim(path string, delim string) ([]pathPart, error) {
+	return parsePath(path, delim, false)
+}
+
+// splitQuery parses a query path as used by Query.  On top of splitPath it reads an unquoted * key or [*] as a
+// wildcard, an empty key between two dots as recursive descent and [start:end] as an array slice.
+func splitQuery(path string) ([]pathPart, error) {
+	return parsePath(path, ".", true)
+}
+
+func parsePath(path string, delim string, query bool) ([]pathPart, error) {
+	var parts []pathPart
+	i := 0
+	for {
+		if query && (strings.HasPrefix(path[i:], delim+delim) && i == 0 ||
+			strings.HasPrefix(path[i:], delim) && i > 0) {
+			parts = append(parts, pathPart{kind: partDescend})
+			if i == 0 {
+				i += len(delim)
+			}
+			i += len(delim)
+			if i == len(path) {
+				return nil, ErrPath
+			}
+		}
+
+		// a key, which is empty when the segment starts with a bracket
+		if i < len(path) && path[i] == '"' {
+			key, next, err := parseQuoted(path, i)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, pathPart{kind: partKey, key: key})
+			i = next
+		} else {
+			var key strings.Builder
+			escaped := false
+			for i < len(path) && path[i] != '[' && !strings.HasPrefix(path[i:], delim) {
+				if path[i] == '\\' && i+1 < len(path) {
+					escaped = true
+					i++
+				}
+				key.WriteByte(path[i])
+				i++
+			}
+			if query && !escaped && key.String() == "*" {
+				parts = append(parts, pathPart{kind: partWildcard})
+			} else if key.Len() > 0 || i == len(path) || path[i] != '[' {
+				parts = append(parts, pathPart{kind: partKey, key: key.String()})
+			}
+		}
+
+		// any number of brackets
+		for i < len(path) && path[i] == '[' {
+			part, next, err := parseBracket(path, i, query)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+			i = next
+		}
+
+		if i == len(path) {
+			return parts, nil
+		}
+		if !strings.HasPrefix(path[i:], delim) {
+			return nil, ErrPath
+		}
+		i += len(delim)
+	}
+}
+
+// parseQuoted reads a key quoted with " or ' starting at path[start], returning the key and the index after the
+// closing quote.
+func parseQuoted(path string, start int) (string, int, error) {
+	quote := path[start]
+	var key strings.Builder
+	for i := start + 1; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 < len(path) {
+				i++
+			}
+			key.WriteByte(path[i])
+		case quote:
+			return key.String(), i + 1, nil
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return "", 0, ErrPath
+}
+
+// parseBracket reads a [n] index or ["key"] starting at path[start], returning the part and the index after the ].
+// In a query it also reads [*] and [start:end].
+func parseBracket(path string, start int, query bool) (pathPart, int, error) {
+	i := start + 1
+	if i < len(path) && (path[i] == '"' || path[i] == '\'') {
+		key, next, err := parseQuoted(path, i)
+		if err != nil {
+			return pathPart{}, 0, err
+		}
+		if next >= len(path) || path[next] != ']' {
+			return pathPart{}, 0, ErrPath
+		}
+		return pathPart{kind: partKey, key: key}, next + 1, nil
+	}
+	end := strings.IndexByte(path[i:], ']')
+	if end == -1 {
+		return pathPart{}, 0, ErrPath
+	}
+	content := strings.TrimSpace(path[i : i+end])
+	next := i + end + 1
+	if content == "" || content == "-" {
+		return pathPart{kind: partAppend}, next, nil
+	}
+	if query && content == "*" {
+		return pathPart{kind: partWildcard}, next, nil
+	}
+	if query && strings.Contains(content, ":") {
+		part, err := parseSlice(content)
+		return part, next, err
+	}
+	if n, err := strconv.Atoi(content); err == nil {
+		return pathPart{kind: partIndex, index: n}, next, nil
+	}
+	return pathPart{kind: partKey, key: content}, next, nil
+}
+
+// parseSlice reads the start:end content of a slice, either of which may be omitted or negative.
+func parseSlice(content string) (pathPart, error) {
+	part := pathPart{kind: partSlice}
+	bounds := strings.Split(content, ":")
+	if len(bounds) != 2 {
+		return pathPart{}, ErrPath
+	}
+	for i, b := range bounds {
+		b = strings.TrimSpace(b)
+		if b == "" {
+			continue
+		}
+		n, err := strconv.Atoi(b)
+		if err != nil {
+			return pathPart{}, ErrPath
+		}
+		if i == 0 {
+			part.start = &n
+		} else {
+			part.end = &n
+		}
+	}
+	return part, nil
+}
+
+// JoinPath joins keys into a path, escaping any dots, brackets, quotes or backslashes in them.
+func JoinPath(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = escapePathKey(part, ".")
+	}
+	return strings.Join(escaped, ".")
+}
+
+// escapePathKey escapes a single key so splitPathDelim reads it back unchanged.
+func escapePathKey(key string, delim string) string {
+	if !strings.ContainsAny(key, `.[]\"`) && (delim == "." || !strings.Contains(key, delim)) {
+		return key
+	}
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '[' || c == ']' || c == '\\' || (c == '"' && i == 0) || (delim == "." && c == '.') ||
+			(delim != "." && strings.HasPrefix(key[i:], delim)) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// formatPath is the inverse of splitPathDelim.
+func formatPath(parts []pathPart, delim string) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		switch part.kind {
+		case partIndex:
+			sb.WriteString("[" + strconv.Itoa(part.index) + "]")
+		case partAppend:
+			sb.WriteString("[]")
+		default:
+			if i > 0 {
+				sb.WriteString(delim)
+			}
+			sb.WriteString(escapePathKey(part.key, delim))
+		}
+	}
+	return sb.String()
+}
+
+// mapOf returns the map behind an Object or map[string]any.
+func mapOf(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case Object:
+		return m, m != nil
+	case map[string]any:
+		return m, m != nil
+	default:
+		return nil, false
+	}
+}
+
+// resolveIndex turns a negative index into an offset from the end of an array of length n.
+func resolveIndex(index int, n int) int {
+	if index < 0 {
+		return n + index
+	}
+	return index
+}
+
+// getPart returns the child of v addressed by part.
+func getPart(v any, part pathPart) (any, bool) {
+	switch part.kind {
+	case partAppend:
+		return nil, false
+	case partIndex:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, false
+		}
+		i := resolveIndex(part.index, rv.Len())
+		if i < 0 || i >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(i).Interface(), true
+	default:
+		m, ok := mapOf(v)
+		if !ok {
+			return nil, false
+		}
+		child, found := m[part.key]
+		return child, found
+	}
+}
+
+func getParts(v any, parts []pathPart) (any, bool) {
+	for _, part := range parts {
+		var found bool
+		if v, found = getPart(v, part); !found {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// setParts writes value at parts below v, creating Objects for missing keys and []any for missing arrays, and growing
+// arrays that are too short, turning a Go array into a slice when it has to grow.  It returns v, or the value that
+// replaced it when v was a slice that had to grow or a value that is neither an Object nor an array.  An index into an
+// Object, or a key into an array, returns ErrPath and leaves them as they were.
+func setParts(v any, parts []pathPart, value any) (any, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	part := parts[0]
+	switch part.kind {
+	case partIndex, partAppend:
+		if _, ok := mapOf(v); ok {
+			return v, ErrPath
+		}
+		rv := addressable(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			rv = reflect.ValueOf([]any{})
+		}
+		i := rv.Len()
+		if part.kind == partIndex {
+			if i = resolveIndex(part.index, rv.Len()); i < 0 {
+				return v, nil
+			}
+		}
+		var child any
+		if i < rv.Len() {
+			child = rv.Index(i).Interface()
+		} else {
+			grown := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), i+1, i+1)
+			reflect.Copy(grown, rv)
+			rv = grown
+		}
+		child, err := setParts(child, parts[1:], value)
+		if err != nil {
+			return v, err
+		}
+		return setIndex(rv, i, child), nil
+	default:
+		m, ok := mapOf(v)
+		if !ok {
+			if isList(reflect.ValueOf(v)) {
+				return v, ErrPath
+			}
+			obj := Object{}
+			m, v = obj, obj
+		}
+		child, err := setParts(m[part.key], parts[1:], value)
+		if err != nil {
+			return v, err
+		}
+		m[part.key] = child
+		return v, nil
+	}
+}
+
+// setIndex stores value in the slice rv, converting the slice to []any if the value does not fit its element type.
+func setIndex(rv reflect.Value, index int, value any) any {
+	rv = addressable(rv)
+	et := rv.Type().Elem()
+	vv := reflect.ValueOf(value)
+	switch {
+	case value == nil && canBeNil(et.Kind()):
+		rv.Index(index).Set(reflect.Zero(et))
+		return rv.Interface()
+	case vv.IsValid() && vv.Type().AssignableTo(et):
+		rv.Index(index).Set(vv)
+		return rv.Interface()
+	case vv.IsValid() && vv.Type().ConvertibleTo(et) && vv.Kind() == et.Kind():
+		rv.Index(index).Set(vv.Convert(et))
+		return rv.Interface()
+	}
+	ret := make([]any, rv.Len())
+	for i := range ret {
+		ret[i] = rv.Index(i).Interface()
+	}
+	ret[index] = value
+	return ret
+}
+
+// deleteParts removes the value at parts below v, returning v or the slice that replaced it when an element was
+// removed from a slice or Go array.
+func deleteParts(v any, parts []pathPart) any {
+	part := parts[0]
+	if len(parts) > 1 {
+		child, found := getPart(v, part)
+		if !found {
+			return v
+		}
+		child = deleteParts(child, parts[1:])
+		if part.kind == partIndex {
+			rv := reflect.ValueOf(v)
+			return setIndex(rv, resolveIndex(part.index, rv.Len()), child)
+		}
+		m, _ := mapOf(v)
+		m[part.key] = child
+		return v
+	}
+	switch part.kind {
+	case partKey:
+		if m, ok := mapOf(v); ok {
+			delete(m, part.key)
+		}
+	case partIndex:
+		rv := addressable(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return v
+		}
+		i := resolveIndex(part.index, rv.Len())
+		if i < 0 || i >= rv.Len() {
+			return v
+		}
+		ret := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), rv.Len()-1, rv.Len()-1)
+		reflect.Copy(ret, rv.Slice(0, i))
+		reflect.Copy(ret.Slice(i, ret.Len()), rv.Slice(i+1, rv.Len()))
+		return ret.Interface()
+	}
+	return v
+}
+
+// insertIndex returns a copy of the slice or Go array v, as a slice, with value inserted before index, which may
+// equal the length of v.
+func insertIndex(v any, index int, value any) any {
+	rv := addressable(reflect.ValueOf(v))
+	ret := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), rv.Len()+1, rv.Len()+1)
+	reflect.Copy(ret, rv.Slice(0, index))
+	reflect.Copy(ret.Slice(index+1, ret.Len()), rv.Slice(index, rv.Len()))
+	return setIndex(ret, index, value)
+}
+
+func canBeNil(k reflect.Kind) bool {
+	switch k {
+	case reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
+
+// addressable returns a settable copy of a Go array that is not addressable, whose elements cannot be set otherwise,
+// or rv itself.
+func addressable(rv reflect.Value) reflect.Value {
+	if rv.Kind() != reflect.Array || rv.CanAddr() {
+		return rv
+	}
+	ret := reflect.New(rv.Type()).Elem()
+	ret.Set(rv)
+	return ret
+}
@@ -0,0 +1,173 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPath(t *testing.T) {
+	parts, err := splitPath(`a.b\.c["d.e"][2]."f[g]".h['i']`)
+	assert.NoError(t, err)
+	assert.Equal(t, []pathPart{
+		{kind: partKey, key: "a"},
+		{kind: partKey, key: "b.c"},
+		{kind: partKey, key: "d.e"},
+		{kind: partIndex, index: 2},
+		{kind: partKey, key: "f[g]"},
+		{kind: partKey, key: "h"},
+		{kind: partKey, key: "i"},
+	}, parts)
+
+	for _, bad := range []string{`a["b`, `a[1`, `"a`, `a[1]b`} {
+		_, err = splitPath(bad)
+		assert.ErrorIs(t, err, ErrPath, bad)
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	assert.Equal(t, "a.b", JoinPath("a", "b"))
+	assert.Equal(t, `sensors\.temp.a\[1\].c\\d`, JoinPath("sensors.temp", "a[1]", `c\d`))
+
+	for _, keys := range [][]string{{"a", "b.c", "d"}, {"x[1]", `"quoted"`, `back\slash`}, {"10.0.0.1"}} {
+		parts, err := splitPath(JoinPath(keys...))
+		assert.NoError(t, err)
+		assert.Len(t, parts, len(keys))
+		for i, part := range parts {
+			assert.Equal(t, keys[i], part.key)
+		}
+	}
+}
+
+func TestEscapedKeys(t *testing.T) {
+	o := Object{"sensors.temp": 21.5, "net": Object{"10.0.0.1": "up"}, "labels": map[string]any{"a[1]": "x"}}
+
+	assert.Equal(t, 21.5, o.Get(`sensors\.temp`))
+	assert.Equal(t, 21.5, o.Get(`"sensors.temp"`))
+	assert.Equal(t, 21.5, o.Get(`["sensors.temp"]`))
+	assert.Nil(t, o.Get("sensors.temp"))
+	assert.Equal(t, "up", o.Get(`net["10.0.0.1"]`))
+	assert.Equal(t, "x", o.Get(JoinPath("labels", "a[1]")))
+	assert.True(t, o.Exists(JoinPath("net", "10.0.0.1")))
+
+	o.Set(JoinPath("net", "10.0.0.2"), "down")
+	assert.Equal(t, "down", o.GetObject("net")["10.0.0.2"])
+
+	o.Move(JoinPath("net", "10.0.0.2"), `moved."a.b"`)
+	assert.Equal(t, "down", o.GetObject("moved")["a.b"])
+	assert.False(t, o.Exists(JoinPath("net", "10.0.0.2")))
+
+	o.Delete(`labels["a[1]"]`)
+	assert.Empty(t, o.GetObject("labels"))
+
+	o.Set("prefixes", Object{"x.1": 1, "x.2": 2, "y": 3})
+	o.DeletePrefix(`prefixes.x\.`)
+	assert.Equal(t, Object{"y": 3}, o.GetObject("prefixes"))
+
+	flat := Object{"a.b": Object{"c": 1}}.FlattenWith(FlattenOptions{Escape: true})
+	assert.Equal(t, Object{`a\.b.c`: 1}, flat)
+	assert.Equal(t, 1, Object{"a.b": Object{"c": 1}}.Get(`a\.b.c`))
+
+	diff := Object{"a/b": 1}.Diff(Object{"a/b": 2})
+	assert.Equal(t, map[string]FieldDiff{`a\/b`: {Old: 1, New: 2}}, diff.Modified)
+}
+
+func TestSetArrayPaths(t *testing.T) {
+	o := Object{
+		"items":   []any{Object{"name": "a"}, Object{"name": "b"}},
+		"objects": []Object{{"id": 1}},
+		"maps":    []map[string]any{{"id": 1}},
+	}
+
+	o.Set("items[1].name", "B")
+	assert.Equal(t, "B", o.Get("items[1].name"))
+	assert.Nil(t, o.Get("items[1]]"))
+	assert.False(t, o.Exists("items[1]]"))
+
+	o.Set("items[-1].name", "last")
+	assert.Equal(t, "last", o.Get("items[1].name"))
+	assert.Equal(t, "a", o.Get("items[-2].name"))
+	assert.Nil(t, o.Get("items[-3]"))
+
+	o.Set("items[]", "appended")
+	o.Set("items[-]", "again")
+	assert.Len(t, o.Get("items"), 4)
+	assert.Equal(t, "again", o.Get("items[-1]"))
+
+	o.Set("items[6]", "far")
+	assert.Len(t, o.Get("items"), 7)
+	assert.Nil(t, o.Get("items[5]"))
+
+	o.Set("objects[0].id", 2)
+	o.Set("objects[2].id", 3)
+	assert.Equal(t, []Object{{"id": 2}, nil, {"id": 3}}, o.Get("objects"))
+
+	o.Set("maps[0].id", 2)
+	o.Set("maps[].id", 3)
+	assert.Equal(t, 2, o.Get("maps[0].id"))
+	assert.Equal(t, 3, o.Get("maps[1].id"))
+
+	o.Set("new[1].x", 1)
+	assert.Equal(t, []any{nil, Object{"x": 1}}, o.Get("new"))
+
+	o.SetIfNotExist("new[0]", "first")
+	o.SetIfNotExist("new[1]", "ignored")
+	assert.Equal(t, "first", o.Get("new[0]"))
+	o.SetNullIfNotExist("new[2]")
+	assert.Len(t, o.Get("new"), 3)
+
+	assert.Equal(t, 5, o.ModifyInt("counts[0]", 5))
+	assert.Equal(t, 7, o.ModifyInt("counts[0]", 2))
+
+	o.Move("items[0].name", "moved[]")
+	assert.Equal(t, []any{"a"}, o.Get("moved"))
+	o.Move("items[0]", "first")
+	assert.Equal(t, Object{}, o.Get("first"))
+	assert.Equal(t, "last", o.Get("items[0].name"))
+
+	o.Delete("objects[1]")
+	assert.Equal(t, []Object{{"id": 2}, {"id": 3}}, o.Get("objects"))
+	o.Delete("maps[-1].id")
+	assert.Equal(t, map[string]any{}, o.Get("maps[1]"))
+
+	// an index into an Object, or a key of an array, leaves them alone, while other values are replaced
+	o = Object{"m": Object{"a": 1}, "l": []any{1}}
+	o.Set("m[0]", 2)
+	o.Set("m[].x", 2)
+	o.Set("l.x", 2)
+	o.Set("l[0].x", 2)
+	o.Move("m.a", "l.a")
+	assert.Equal(t, Object{"m": Object{"a": 1}, "l": []any{Object{"x": 2}}}, o)
+	_, err := setParts(o, []pathPart{{kind: partKey, key: "m"}, {kind: partIndex}}, 2)
+	assert.ErrorIs(t, err, ErrPath)
+	_, err = setParts(o, []pathPart{{kind: partKey, key: "l"}, {kind: partKey, key: "x"}}, 2)
+	assert.ErrorIs(t, err, ErrPath)
+}
+
+func TestGoArrayPaths(t *testing.T) {
+	o := Object{"arr": [3]int{1, 2, 3}, "objs": [2]any{Object{"x": 1}, 2}}
+
+	o.Set("arr[1]", 9)
+	assert.Equal(t, [3]int{1, 9, 3}, o.Get("arr"))
+	o.Set("arr[-1]", 8)
+	assert.Equal(t, [3]int{1, 9, 8}, o.Get("arr"))
+	o.Set("arr[4]", 5)
+	assert.Equal(t, []int{1, 9, 8, 0, 5}, o.Get("arr"))
+
+	o.Set("objs[0].y", 2)
+	assert.Equal(t, Object{"x": 1, "y": 2}, o.Get("objs[0]"))
+	o.Delete("objs[0].x")
+	assert.Equal(t, [2]any{Object{"y": 2}, 2}, o.Get("objs"))
+	o.Set("objs[1]", "two")
+	assert.Equal(t, [2]any{Object{"y": 2}, "two"}, o.Get("objs"))
+	o.Delete("objs[0]")
+	assert.Equal(t, []any{"two"}, o.Get("objs"))
+
+	o["arr"] = [3]string{"a", "b", "c"}
+	o.Set("arr[1]", 2)
+	assert.Equal(t, []any{"a", 2, "c"}, o.Get("arr"))
+
+	doc := Object{"arr": [2]int{1, 3}}
+	assert.NoError(t, doc.ApplyPatch([]PatchOp{{Op: PatchAdd, Path: "/arr/1", Value: 2}}))
+	assert.Equal(t, []int{1, 2, 3}, doc["arr"])
+}
@@ -0,0 +1,162 @@
+package tox
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// splitPointer parses an RFC 6901 JSON Pointer such as "/a/b~1c/0" into its unescaped reference tokens.  The empty
+// pointer refers to the whole document and has no tokens.
+func splitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, ErrPath
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, ErrPath
+			}
+		}
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// isPointerIndex reports whether token is an array index as RFC 6901 writes them, without sign or leading zeros.
+func isPointerIndex(token string) bool {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return false
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pointerParts resolves the tokens of ptr against v.  A token addressing an array becomes an index, or an append
+// for "-", and every other token becomes a key, so "/labels/0" reads the key "0" of a map but the first element of
+// an array.
+func pointerParts(v any, ptr string) ([]pathPart, error) {
+	tokens, err := splitPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]pathPart, len(tokens))
+	for i, token := range tokens {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			switch {
+			case token == "-":
+				parts[i] = pathPart{kind: partAppend}
+			case isPointerIndex(token):
+				n, err := strconv.Atoi(token)
+				if err != nil {
+					return nil, ErrPath
+				}
+				parts[i] = pathPart{kind: partIndex, index: n}
+			default:
+				return nil, ErrPath
+			}
+		} else {
+			parts[i] = pathPart{kind: partKey, key: token}
+		}
+		v, _ = getPart(v, parts[i])
+	}
+	return parts, nil
+}
+
+// GetPointer returns the value at an RFC 6901 JSON Pointer such as "/devices/0/name", or nil if it does not exist.
+func (o Object) GetPointer(ptr string) any {
+	if o == nil {
+		return nil
+	}
+	parts, err := pointerParts(o, ptr)
+	if err != nil {
+		return nil
+	}
+	v, _ := getParts(o, parts)
+	return v
+}
+
+// SetPointer sets the value at a JSON Pointer, where a final "-" appends to an array.  Missing objects along the way
+// are created as with Set, but unlike Set the value is stored as given, so nil and empty values are kept.
+func (o Object) SetPointer(ptr string, value any) {
+	if o == nil {
+		return
+	}
+	parts, err := pointerParts(o, ptr)
+	if err != nil || len(parts) == 0 {
+		return
+	}
+	setParts(o, parts, value)
+}
+
+// DeletePointer removes the value at a JSON Pointer, removing the element from its array when it addresses one.
+func (o Object) DeletePointer(ptr string) {
+	if o == nil {
+		return
+	}
+	parts, err := pointerParts(o, ptr)
+	if err != nil || len(parts) == 0 {
+		return
+	}
+	deleteParts(o, parts)
+}
+
+// PointerToPath converts a JSON Pointer to the dotted path syntax of Get and Set.  Without the data to resolve
+// against, tokens that look like array indexes become indexes and "-" becomes an append.
+func PointerToPath(ptr string) (string, error) {
+	tokens, err := splitPointer(ptr)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]pathPart, len(tokens))
+	for i, token := range tokens {
+		parts[i] = pathPart{kind: partKey, key: token}
+		if token == "-" {
+			parts[i] = pathPart{kind: partAppend}
+		} else if n, err := strconv.Atoi(token); err == nil && isPointerIndex(token) {
+			parts[i] = pathPart{kind: partIndex, index: n}
+		}
+	}
+	return formatPath(parts, "."), nil
+}
+
+// PathToPointer converts a dotted path to a JSON Pointer.  Negative indexes have no JSON Pointer equivalent and
+// return ErrPath.
+func PathToPointer(path string) (string, error) {
+	parts, err := splitPath(path)
+	if err != nil {
+		return "", err
+	}
+	return formatPointer(parts)
+}
+
+func formatPointer(parts []pathPart) (string, error) {
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteByte('/')
+		switch part.kind {
+		case partIndex:
+			if part.index < 0 {
+				return "", ErrPath
+			}
+			sb.WriteString(strconv.Itoa(part.index))
+		case partAppend:
+			sb.WriteByte('-')
+		default:
+			sb.WriteString(pointerEscaper.Replace(part.key))
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,65 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointer(t *testing.T) {
+	o := Object{
+		"a":      Object{"b/c": 1, "m~n": 2},
+		"items":  []any{"x", "y"},
+		"labels": map[string]any{"0": "zero"},
+	}
+
+	assert.Equal(t, o, o.GetPointer(""))
+	assert.Equal(t, 1, o.GetPointer("/a/b~1c"))
+	assert.Equal(t, 2, o.GetPointer("/a/m~0n"))
+	assert.Equal(t, "y", o.GetPointer("/items/1"))
+	assert.Equal(t, "zero", o.GetPointer("/labels/0"))
+	assert.Nil(t, o.GetPointer("/items/01"))
+	assert.Nil(t, o.GetPointer("/items/-"))
+	assert.Nil(t, o.GetPointer("a"))
+	assert.Nil(t, o.GetPointer("/a/b~2c"))
+
+	o.SetPointer("/items/-", "z")
+	o.SetPointer("/items/0", nil)
+	assert.Equal(t, []any{nil, "y", "z"}, o.Get("items"))
+	o.SetPointer("/labels/1", "one")
+	assert.Equal(t, "one", o.Get("labels.1"))
+	o.SetPointer("/new/x~1y", "")
+	assert.Equal(t, "", o.Get(`new.x/y`))
+	o.SetPointer("/items/name", "ignored")
+	assert.Len(t, o.Get("items"), 3)
+
+	o.DeletePointer("/items/0")
+	assert.Equal(t, []any{"y", "z"}, o.Get("items"))
+	o.DeletePointer("/a/b~1c")
+	assert.Equal(t, Object{"m~n": 2}, o.Get("a"))
+	o.DeletePointer("/labels/0")
+	assert.False(t, o.Exists("labels.0"))
+}
+
+func TestPointerConversion(t *testing.T) {
+	path, err := PointerToPath("/a/b~1c/0/x.y/-")
+	assert.NoError(t, err)
+	assert.Equal(t, `a.b/c[0].x\.y[]`, path)
+
+	ptr, err := PathToPointer(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "/a/b~1c/0/x.y/-", ptr)
+
+	ptr, err = PathToPointer(`"m~n".items[2]`)
+	assert.NoError(t, err)
+	assert.Equal(t, "/m~0n/items/2", ptr)
+
+	path, err = PointerToPath("/0/01")
+	assert.NoError(t, err)
+	assert.Equal(t, "[0].01", path)
+
+	_, err = PointerToPath("a/b")
+	assert.ErrorIs(t, err, ErrPath)
+	_, err = PathToPointer("items[-1]")
+	assert.ErrorIs(t, err, ErrPath)
+}
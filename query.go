@@ -0,0 +1,188 @@
+package tox
+
+import (
+	"reflect"
+	"sort"
+)
+
+// QueryResult is a single match of Query, with the concrete path that Get would use to read the same value, or of a
+// JSONPath, with its normalized path.
+type QueryResult struct {
+	Path  string
+	Value any
+}
+
+// Query returns every value matching path, which is a path as accepted by Get that may also contain:
+//
+//	[*]        any element of an array
+//	*          any key of a map
+//	..         recursive descent, matching the rest of the path at any depth
+//	[1:3]      a slice of an array, where either bound may be omitted or negative
+//
+// For example "devices[*].sensors.*.value" or "..value".  Map keys are visited in sorted order.  An invalid path
+// returns nil.
+func (o Object) Query(path string) []QueryResult {
+	if o == nil {
+		return nil
+	}
+	parts, err := splitQuery(path)
+	if err != nil {
+		return nil
+	}
+	var results []QueryResult
+	queryParts(o, parts, nil, func(at []pathPart, v any) {
+		results = append(results, QueryResult{Path: formatPath(at, "."), Value: v})
+	})
+	return results
+}
+
+// queryParts calls emit for every value matching parts below v, where at is the concrete path to v.
+func queryParts(v any, parts []pathPart, at []pathPart, emit func([]pathPart, any)) {
+	if len(parts) == 0 {
+		emit(at, v)
+		return
+	}
+	part, rest := parts[0], parts[1:]
+	switch part.kind {
+	case partKey:
+		if child, found := getPart(v, part); found {
+			queryParts(child, rest, appendPart(at, part), emit)
+		}
+	case partIndex:
+		if child, found := getPart(v, part); found {
+			i := resolveIndex(part.index, reflect.ValueOf(v).Len())
+			queryParts(child, rest, appendPart(at, pathPart{kind: partIndex, index: i}), emit)
+		}
+	case partWildcard:
+		eachChild(v, func(child any, p pathPart) {
+			queryParts(child, rest, appendPart(at, p), emit)
+		})
+	case partSlice:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return
+		}
+		start, end := sliceBounds(part, rv.Len())
+		for i := start; i < end; i++ {
+			queryParts(rv.Index(i).Interface(), rest, appendPart(at, pathPart{kind: partIndex, index: i}), emit)
+		}
+	case partDescend:
+		queryParts(v, rest, at, emit)
+		eachChild(v, func(child any, p pathPart) {
+			queryParts(child, parts, appendPart(at, p), emit)
+		})
+	}
+}
+
+// eachChild calls fn for every key of a map with string keys, in sorted order, or every element of an array.
+func eachChild(v any, fn func(any, pathPart)) {
+	if m, ok := mapOf(v); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fn(m[k], pathPart{kind: partKey, key: k})
+		}
+		return
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case isStringMap(rv):
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fn(rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface(), pathPart{kind: partKey, key: k})
+		}
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fn(rv.Index(i).Interface(), pathPart{kind: partIndex, index: i})
+		}
+	}
+}
+
+// sliceBounds resolves the bounds of a slice part against an array of length n, clamping them into range.
+func sliceBounds(part pathPart, n int) (int, int) {
+	start, end := 0, n
+	if part.start != nil {
+		start = clampIndex(resolveIndex(*part.start, n), n)
+	}
+	if part.end != nil {
+		end = clampIndex(resolveIndex(*part.end, n), n)
+	}
+	return start, end
+}
+
+func clampIndex(i int, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// appendPart returns at with part added, without sharing storage with other branches of a query.
+func appendPart(at []pathPart, part pathPart) []pathPart {
+	return append(at[:len(at):len(at)], part)
+}
+
+// splitPattern parses a query used to match concrete paths with matchQuery, as in DiffOptions.Ignore and
+// MergeRule.Path.  Patterns are matched without the data, so negative indexes and slice bounds, which count from the
+// end of an array, are rejected with ErrPath.
+func splitPattern(path string) ([]pathPart, error) {
+	parts, err := splitQuery(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		if part.kind == partIndex && part.index < 0 || part.kind == partSlice &&
+			(part.start != nil && *part.start < 0 || part.end != nil && *part.end < 0) {
+			return nil, ErrPath
+		}
+	}
+	return parts, nil
+}
+
+// matchQuery reports whether the concrete path parts matches the query pattern, as parsed by splitPattern.
+func matchQuery(pattern []pathPart, parts []pathPart) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	p := pattern[0]
+	if p.kind == partDescend {
+		for i := 0; i <= len(parts); i++ {
+			if matchQuery(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	q := parts[0]
+	switch p.kind {
+	case partWildcard:
+	case partKey:
+		if q.kind != partKey || q.key != p.key {
+			return false
+		}
+	case partIndex:
+		if q.kind != partIndex || q.index != p.index {
+			return false
+		}
+	case partSlice:
+		if q.kind != partIndex || (p.start != nil && q.index < *p.start) || (p.end != nil && q.index >= *p.end) {
+			return false
+		}
+	default:
+		return false
+	}
+	return matchQuery(pattern[1:], parts[1:])
+}
@@ -0,0 +1,84 @@
+package tox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	o := Object{
+		"devices": []any{
+			Object{"id": "a", "sensors": Object{"temp": Object{"value": 21}, "hum": Object{"value": 40}}},
+			Object{"id": "b", "sensors": map[string]any{"temp": map[string]any{"value": 19}}},
+			Object{"id": "c"},
+		},
+		"list":       []int{0, 1, 2, 3, 4},
+		"*":          "star",
+		"value":      1,
+		"sensors.id": "dotted",
+	}
+
+	assert.Equal(t, []QueryResult{
+		{Path: "devices[0].sensors.hum.value", Value: 40},
+		{Path: "devices[0].sensors.temp.value", Value: 21},
+		{Path: "devices[1].sensors.temp.value", Value: 19},
+	}, o.Query("devices[*].sensors.*.value"))
+
+	assert.Equal(t, []QueryResult{
+		{Path: "value", Value: 1},
+		{Path: "devices[0].sensors.hum.value", Value: 40},
+		{Path: "devices[0].sensors.temp.value", Value: 21},
+		{Path: "devices[1].sensors.temp.value", Value: 19},
+	}, o.Query("..value"))
+
+	assert.Equal(t, []QueryResult{
+		{Path: "devices[0].id", Value: "a"},
+		{Path: "devices[1].id", Value: "b"},
+		{Path: "devices[2].id", Value: "c"},
+	}, o.Query("devices..id"))
+
+	assert.Equal(t, []QueryResult{{Path: "list[1]", Value: 1}, {Path: "list[2]", Value: 2}}, o.Query("list[1:3]"))
+	assert.Equal(t, []QueryResult{{Path: "list[3]", Value: 3}, {Path: "list[4]", Value: 4}}, o.Query("list[-2:]"))
+	assert.Len(t, o.Query("list[:10]"), 5)
+	assert.Empty(t, o.Query("list[4:2]"))
+	assert.Equal(t, []QueryResult{{Path: "devices[2].id", Value: "c"}}, o.Query("devices[-1].id"))
+
+	assert.Equal(t, []QueryResult{{Path: "*", Value: "star"}}, o.Query(`\*`))
+	assert.Equal(t, []QueryResult{{Path: `sensors\.id`, Value: "dotted"}}, o.Query(`"sensors.id"`))
+
+	for _, r := range o.Query("..*") {
+		assert.Equal(t, r.Value, o.Get(r.Path), r.Path)
+	}
+
+	assert.Nil(t, o.Query("devices[1:x]"))
+	assert.Nil(t, o.Query("devices.."))
+	assert.Nil(t, o.Query("missing[*]"))
+}
+
+func TestMatchPattern(t *testing.T) {
+	for _, bad := range []string{"a[-1]", "a[-2:]", "a[:-1]", "a[1:-1].b"} {
+		_, err := splitPattern(bad)
+		assert.ErrorIs(t, err, ErrPath, bad)
+	}
+	for query, paths := range map[string][2]string{
+		"a[1:]":    {"a[2]", "a[0]"},
+		"a[:2].b":  {"a[1].b", "a[2].b"},
+		"a[0]":     {"a[0]", "a[1]"},
+		"..x[1:3]": {"p.q.x[2]", "x[3]"},
+	} {
+		pattern, err := splitPattern(query)
+		assert.NoError(t, err, query)
+		match, _ := splitPath(paths[0])
+		miss, _ := splitPath(paths[1])
+		assert.True(t, matchQuery(pattern, match), query)
+		assert.False(t, matchQuery(pattern, miss), query)
+	}
+
+	a := Object{"items": []any{1, 2, 3}}
+	b := Object{"items": []any{1, 5, 6}}
+	diff := a.DiffWith(b, DiffOptions{Ignore: []string{"items[1:]"}})
+	assert.True(t, diff.Same)
+	diff = a.DiffWith(b, DiffOptions{Ignore: []string{"items[-1]"}})
+	assert.Len(t, diff.Modified, 2)
+}
@@ -1,6 +1,7 @@
 package tox
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"math"
@@ -31,48 +32,60 @@ func toStringMap(in map[any]any) map[string]any {
 func ToString(v interface{}) string {
 	return ToStringOpts(v, nil)
 }
+
 func ToStringOpts(v interface{}, options *Options) string {
+	s, _ := ToStringOptsE(v, options)
+	return s
+}
+
+// ToStringE converts any data type to a string, returning an error if the value cannot be represented.  The returned
+// string is the fmt.Sprintf() representation that ToString would have used.
+func ToStringE(v interface{}) (string, error) {
+	return ToStringOptsE(v, nil)
+}
+
+func ToStringOptsE(v interface{}, options *Options) (string, error) {
 	switch v := v.(type) {
 	case bool:
 		if v {
-			return "true"
+			return "true", nil
 		}
-		return "false"
+		return "false", nil
 	case nil:
-		return ""
+		return "", nil
 	case string:
-		return v
+		return v, nil
 	case float64:
 		if options != nil && options.FloatToInt {
 			if v == math.Floor(v) {
-				return fmt.Sprintf("%.0f", v)
+				return fmt.Sprintf("%.0f", v), nil
 			}
 		}
 		if options != nil && options.FloatPrecision > 0 {
-			return fmt.Sprintf("%.*f", options.FloatPrecision, v)
+			return fmt.Sprintf("%.*f", options.FloatPrecision, v), nil
 		} else {
-			return fmt.Sprintf("%v", v)
+			return fmt.Sprintf("%v", v), nil
 		}
 	case float32:
-		return ToStringOpts(float64(v), options)
+		return ToStringOptsE(float64(v), options)
 	case int, int64, uint, uint64, int8, int16, uint8, uint16:
-		return fmt.Sprintf("%v", v)
+		return fmt.Sprintf("%v", v), nil
 	case time.Duration:
-		return ToPrettyDuration(v, FormatShort)
+		return ToPrettyDuration(v, FormatShort), nil
 	case time.Time:
-		return v.Format(time.RFC3339Nano)
+		return v.Format(time.RFC3339Nano), nil
 	case []byte:
 		if utf8.Valid(v) {
-			return string(v)
+			return string(v), nil
 		} else {
-			return fmt.Sprintf("%v", v)
+			return fmt.Sprintf("%v", v), nil
 		}
 	case map[string]any:
 		b, err := json.Marshal(v)
 		if err != nil {
-			return fmt.Sprintf("%v", v)
+			return fmt.Sprintf("%v", v), marshalError(v, err)
 		} else {
-			return string(b)
+			return string(b), nil
 		}
 	case map[any]any:
 		b, err := json.Marshal(v)
@@ -80,37 +93,98 @@ func ToStringOpts(v interface{}, options *Options) string {
 			var typeErr *json.UnsupportedTypeError
 			if errors.As(err, &typeErr) {
 				fm := toStringMap(v)
-				return ToJson(fm)
+				return ToJson(fm), nil
 			}
-			return fmt.Sprintf("%v", v)
+			return fmt.Sprintf("%v", v), marshalError(v, err)
 		} else {
-			return string(b)
+			return string(b), nil
 		}
 	default:
-
-		if reflect.ValueOf(v).Kind() == reflect.Ptr {
-			if reflect.ValueOf(v).IsNil() {
-				return ""
-			} else {
-				return ToString(reflect.ValueOf(v).Elem().Interface())
+		if cv, found, err := convertRegistered(v, stringType); found {
+			if err != nil {
+				return fmt.Sprintf("%v", v), err
 			}
+			return ToStringOptsE(cv, options)
 		}
 
-		switch reflect.TypeOf(v).Name() {
-		case "DateTime":
-			if reflect.TypeOf(v).Kind() == reflect.Int64 {
-				return time.Unix(reflect.ValueOf(v).Int()/1000, 0).Format(time.RFC3339Nano)
-			}
+		if reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+			return "", nil
+		}
+
+		if s, found, err := stringMethod(v, options.stringMethods()); found {
+			return s, err
+		}
+
+		if bv, ok := namedBasic(v); ok {
+			return ToStringOptsE(bv, options)
 		}
+
+		if reflect.ValueOf(v).Kind() == reflect.Ptr {
+			return ToStringOptsE(reflect.ValueOf(v).Elem().Interface(), options)
+		}
+
 		b, err := json.Marshal(v)
 		if err != nil {
-			return fmt.Sprintf("%v", v)
+			return fmt.Sprintf("%v", v), marshalError(v, err)
 		} else {
-			return string(b)
+			return string(b), nil
 		}
 	}
 }
 
+// stringMethod converts v with the first of methods that it implements.  Values are also checked through a pointer so
+// methods with pointer receivers are found.
+func stringMethod(v any, methods []StringMethod) (string, bool, error) {
+	candidates := []any{v}
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		candidates = append(candidates, p.Interface())
+	}
+	for _, method := range methods {
+		for _, c := range candidates {
+			switch method {
+			case MethodTextMarshaler:
+				if tm, ok := c.(encoding.TextMarshaler); ok {
+					b, err := tm.MarshalText()
+					if err != nil {
+						return fmt.Sprintf("%v", v), true, newConversionError(v, "string", err)
+					}
+					return string(b), true, nil
+				}
+			case MethodStringer:
+				if s, ok := c.(fmt.Stringer); ok {
+					return s.String(), true, nil
+				}
+			case MethodJSONMarshaler:
+				if jm, ok := c.(interface{ MarshalJSON() ([]byte, error) }); ok {
+					b, err := jm.MarshalJSON()
+					if err != nil {
+						return fmt.Sprintf("%v", v), true, marshalError(v, err)
+					}
+					var s string
+					if json.Unmarshal(b, &s) == nil {
+						return s, true, nil
+					}
+					return string(b), true, nil
+				}
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// textOf returns the text of a value implementing encoding.TextMarshaler or fmt.Stringer, so the numeric, bool and
+// time conversions can parse domain types the same way ToString prints them.
+func textOf(v any) (string, bool) {
+	s, found, err := stringMethod(v, []StringMethod{MethodTextMarshaler, MethodStringer})
+	return s, found && err == nil
+}
+
+func marshalError(v any, err error) error {
+	return newConversionError(v, "string", fmt.Errorf("%w: %v", ErrUnsupportedType, err))
+}
+
 func ToJson(v interface{}) string {
 	b, err := json.Marshal(v)
 	if err == nil {
@@ -157,76 +231,6 @@ func ToStringArray(v interface{}) []string {
 	}
 }
 
-type StringFormat int
-
-const (
-	FormatShort  StringFormat = 1
-	FormatMedium StringFormat = 2
-	FormatLong   StringFormat = 3
-)
-
-func ToPrettyDuration(v time.Duration, format StringFormat) string {
-	var r []string
-	isNegative := false
-	if v < 0 {
-		v = -v
-		isNegative = true
-	}
-	days := v / (24 * time.Hour)
-	if days > 0 {
-		switch format {
-		case FormatMedium:
-			r = append(r, fmt.Sprintf("%dd", days))
-		case FormatLong:
-			r = append(r, fmt.Sprintf("%d days", days))
-		}
-	}
-	v -= days * 24 * time.Hour
-
-	hours := v / time.Hour
-	if hours > 0 {
-		switch format {
-		case FormatMedium:
-			r = append(r, fmt.Sprintf("%dh", hours))
-		case FormatLong:
-			r = append(r, fmt.Sprintf("%d hours", hours))
-		}
-	}
-	v -= hours * time.Hour
-
-	minutes := v / time.Minute
-	if minutes > 0 {
-		switch format {
-		case FormatMedium:
-			r = append(r, fmt.Sprintf("%dm", minutes))
-		case FormatLong:
-			r = append(r, fmt.Sprintf("%d minutes", minutes))
-		}
-	}
-	v -= minutes * time.Minute
-
-	seconds := v / time.Second
-	if seconds > 0 {
-		switch format {
-		case FormatMedium:
-			r = append(r, fmt.Sprintf("%ds", seconds))
-		case FormatLong:
-			r = append(r, fmt.Sprintf("%d seconds", seconds))
-		}
-	}
-
-	var ret string
-	if format == FormatShort {
-		ret = fmt.Sprintf("%d.%d:%d:%d", days, hours, minutes, seconds)
-	} else {
-		ret = strings.Join(r, ", ")
-	}
-	if isNegative {
-		ret = "-" + ret
-	}
-	return ret
-}
-
 func ToStringPtr(v interface{}) *string {
 	if v == nil {
 		return nil
@@ -278,6 +282,111 @@ func CapitalizeString(s string) string {
 	return result
 }
 
+// SnakeCase converts s to snake_case, splitting it into words as splitWords does, so "userID", "User Id" and
+// "user-id" all become "user_id".  Leading and trailing characters other than letters and digits are kept, so "_id"
+// and "$setOnInsert" become "_id" and "$set_on_insert".
+func SnakeCase(s string) string {
+	return convertCase(s, "_", func(i int, word string) string {
+		return strings.ToLower(word)
+	})
+}
+
+// CamelCase converts s to camelCase, splitting it into words as SnakeCase does, so "device_id" and "DeviceID" both
+// become "deviceId".
+func CamelCase(s string) string {
+	return convertCase(s, "", func(i int, word string) string {
+		if i == 0 {
+			return strings.ToLower(word)
+		}
+		return titleWord(word)
+	})
+}
+
+// PascalCase converts s to PascalCase, so "device_id" and "deviceID" both become "DeviceId".
+func PascalCase(s string) string {
+	return convertCase(s, "", func(i int, word string) string {
+		return titleWord(word)
+	})
+}
+
+// KebabCase converts s to kebab-case, so "deviceID" becomes "device-id".
+func KebabCase(s string) string {
+	return convertCase(s, "-", func(i int, word string) string {
+		return strings.ToLower(word)
+	})
+}
+
+// ScreamingSnakeCase converts s to SCREAMING_SNAKE_CASE, so "deviceID" becomes "DEVICE_ID".
+func ScreamingSnakeCase(s string) string {
+	return convertCase(s, "_", func(i int, word string) string {
+		return strings.ToUpper(word)
+	})
+}
+
+// convertCase splits s into words, converts each with word and joins them with sep.  Leading and trailing characters
+// other than letters and digits, such as the "_" of "_id" or the "$" of "$set", are kept as they are.
+func convertCase(s string, sep string, word func(i int, word string) string) string {
+	runes := []rune(s)
+	start, end := 0, len(runes)
+	for start < end && !isWordRune(runes[start]) {
+		start++
+	}
+	for end > start && !isWordRune(runes[end-1]) {
+		end--
+	}
+	words := splitWords(string(runes[start:end]))
+	for i, w := range words {
+		words[i] = word(i, w)
+	}
+	return string(runes[:start]) + strings.Join(words, sep) + string(runes[end:])
+}
+
+// titleWord returns word in lower case with its first letter in upper case.
+func titleWord(word string) string {
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// splitWords splits s into words at anything other than letters and digits, and at changes of case, so "HTTPServer"
+// is "HTTP" and "Server", and "userID2" is "user" and "ID2".  An acronym followed by a lone "s" is a plural, so
+// "myURLs" is "my" and "URLs".
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !isWordRune(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			plural := nextLower && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+			if unicode.IsLower(prev) || unicode.IsUpper(prev) && nextLower && !plural ||
+				unicode.IsDigit(prev) && (nextLower || i-1 > start && unicode.IsLower(runes[i-2])) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
 func StringInArray(s string, arr []string, ignoreCase bool) bool {
 	if arr == nil {
 		return false
@@ -0,0 +1,125 @@
+package tox
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type color int
+
+func (c color) String() string {
+	return [...]string{"red", "green", "blue"}[c]
+}
+
+type status string
+
+type fileMode struct {
+	N int `json:"n"`
+}
+
+func (m fileMode) String() string {
+	return "mode " + ToString(m.N)
+}
+
+type level struct {
+	n int
+}
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte(ToString(l.n)), nil
+}
+
+func (l *level) UnmarshalText(b []byte) error {
+	n, err := ToIntE(string(b))
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return errors.New("negative level")
+	}
+	l.n = n
+	return nil
+}
+
+func (l level) String() string {
+	return "level " + ToString(l.n)
+}
+
+func TestToStringMethods(t *testing.T) {
+	assert.Equal(t, "10.0.0.1", ToString(net.ParseIP("10.0.0.1")))
+	assert.Equal(t, "green", ToString(color(1)))
+	assert.Equal(t, "active", ToString(status("active")))
+	assert.Equal(t, "0123456789abcdef01234567", ToString(mockObjectID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}))
+
+	assert.Equal(t, "3", ToString(level{n: 3}))
+	assert.Equal(t, "3", ToString(&level{n: 3}))
+	opts := &Options{StringMethods: []StringMethod{MethodStringer, MethodTextMarshaler}}
+	assert.Equal(t, "level 3", ToStringOpts(level{n: 3}, opts))
+	assert.Equal(t, "{\"n\":1}", ToStringOpts(fileMode{N: 1}, &Options{StringMethods: []StringMethod{}}))
+	assert.Equal(t, "mode 1", ToString(fileMode{N: 1}))
+}
+
+func TestParseTextValues(t *testing.T) {
+	assert.Equal(t, 3, ToInt(level{n: 3}))
+	assert.Equal(t, 3.0, ToFloat64(level{n: 3}))
+	assert.Equal(t, true, ToBool(level{n: 1}))
+
+	// named numeric types convert by value, not by their String()
+	assert.Equal(t, 1, ToInt(color(1)))
+
+	assert.Equal(t, level{n: 7}, To[level]("7"))
+	_, err := ToE[level]("-1")
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.Equal(t, net.ParseIP("10.0.0.2"), To[net.IP]("10.0.0.2"))
+
+	o := NewObject(map[string]any{})
+	o.Set("level", ToString(level{n: 4}))
+	assert.Equal(t, level{n: 4}, To[level](o.Get("level")))
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"userID":           "user_id",
+		"HTTPServer":       "http_server",
+		"already_snake":    "already_snake",
+		"kebab-case":       "kebab_case",
+		"Two words":        "two_words",
+		"getHTTP2Response": "get_http2_response",
+		"v2Api":            "v2_api",
+		"_id":              "_id",
+		"__v":              "__v",
+		"$set":             "$set",
+		"IDs":              "ids",
+		"myURLs":           "my_urls",
+		"HTTPStatus":       "http_status",
+	} {
+		assert.Equal(t, want, SnakeCase(in), in)
+	}
+}
+
+func TestCaseConversions(t *testing.T) {
+	for in, want := range map[string][4]string{
+		"deviceID":        {"deviceId", "DeviceId", "device-id", "DEVICE_ID"},
+		"device_id":       {"deviceId", "DeviceId", "device-id", "DEVICE_ID"},
+		"DeviceID":        {"deviceId", "DeviceId", "device-id", "DEVICE_ID"},
+		"HTTPServerPort":  {"httpServerPort", "HttpServerPort", "http-server-port", "HTTP_SERVER_PORT"},
+		"SENSOR_VALUE_2":  {"sensorValue2", "SensorValue2", "sensor-value-2", "SENSOR_VALUE_2"},
+		"already-kebab x": {"alreadyKebabX", "AlreadyKebabX", "already-kebab-x", "ALREADY_KEBAB_X"},
+		"_id":             {"_id", "_Id", "_id", "_ID"},
+		"__v":             {"__v", "__V", "__v", "__V"},
+		"$setOnInsert":    {"$setOnInsert", "$SetOnInsert", "$set-on-insert", "$SET_ON_INSERT"},
+		"IDs":             {"ids", "Ids", "ids", "IDS"},
+		"myURLs":          {"myUrls", "MyUrls", "my-urls", "MY_URLS"},
+		"deviceIDsList":   {"deviceIdsList", "DeviceIdsList", "device-ids-list", "DEVICE_IDS_LIST"},
+		"_$":              {"_$", "_$", "_$", "_$"},
+		"":                {"", "", "", ""},
+	} {
+		assert.Equal(t, want[0], CamelCase(in), in)
+		assert.Equal(t, want[1], PascalCase(in), in)
+		assert.Equal(t, want[2], KebabCase(in), in)
+		assert.Equal(t, want[3], ScreamingSnakeCase(in), in)
+	}
+}
@@ -168,3 +168,11 @@ func TestMongoObjectIdHandling(t *testing.T) {
 	assert.Equal(t, oid, decoded.Id)
 	assert.Equal(t, &parentOid, decoded.ParentId)
 }
+
+func TestNilPointerField(t *testing.T) {
+	obj := NewObject(struct {
+		ID *mockObjectID `json:"id"`
+	}{})
+	assert.Contains(t, obj, "id")
+	assert.Nil(t, obj["id"])
+}
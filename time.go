@@ -1,82 +1,75 @@
 package tox
 
 import (
-	"reflect"
-	"strconv"
-	"strings"
 	"time"
 )
 
-// ToTime converts data types to time.Time structures.  'int' or 'int64' are treated as unix time, strings are treated
-// as RFC3330Nano timestamps.  If the conversion fails, an empty time.Time{} is returned.
+// ToTime converts data types to time.Time structures.  Numbers are treated as unix epoch times and strings are parsed
+// with ParseTime, both according to the options set with SetDefaultTimeOptions.  If the conversion fails, an empty
+// time.Time{} is returned.
 func ToTime(v interface{}) time.Time {
+	return ToTimeOpts(v, nil)
+}
+
+// ToTimeE converts data types to time.Time structures the same way as ToTime, returning an error if the value cannot
+// be converted.
+func ToTimeE(v interface{}) (time.Time, error) {
+	return ToTimeOptsE(v, nil)
+}
+
+// ToTimeOpts converts data types to time.Time structures using options.Time, or the defaults if it is not set.
+func ToTimeOpts(v interface{}, options *Options) time.Time {
+	t, err := ToTimeOptsE(v, options)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func ToTimeOptsE(v interface{}, options *Options) (time.Time, error) {
+	opts := timeOptions(options)
 	switch v := v.(type) {
-	case int:
-		return time.Unix(int64(v), 0)
-	case int64:
-		return time.Unix(v, 0)
 	case time.Time:
-		return v
-	case string:
-		t, err := time.Parse(time.RFC3339Nano, v)
+		return v, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		n, err := ToInt64E(v)
 		if err != nil {
-			return time.Time{}
+			return time.Time{}, err
 		}
-		return t
+		return epochTime(n, opts.EpochUnit), nil
+	case float32:
+		return epochFloatTime(float64(v), opts.EpochUnit)
+	case float64:
+		return epochFloatTime(v, opts.EpochUnit)
+	case string:
+		return ParseTime(v, opts)
+	case nil:
+		return time.Time{}, newConversionError(v, "time.Time", ErrUnsupportedType)
 	default:
-		if reflect.TypeOf(v).Kind() == reflect.Int64 && reflect.TypeOf(v).Name() == "DateTime" {
-			// special case for mongodb primitive.DateTime
-			return time.Unix(reflect.ValueOf(v).Int()/1000, 0)
+		if cv, found, err := convertRegistered(v, timeType, stringType); found {
+			if err != nil {
+				return time.Time{}, err
+			}
+			return ToTimeOptsE(cv, options)
 		}
-		return time.Time{}
+		if bv, ok := namedBasic(v); ok {
+			return ToTimeOptsE(bv, options)
+		}
+		if s, ok := textOf(v); ok {
+			return ToTimeOptsE(s, options)
+		}
+		return time.Time{}, newConversionError(v, "time.Time", ErrUnsupportedType)
 	}
 }
 
 func ToTimePtr(v interface{}) *time.Time {
+	return ToTimePtrOpts(v, nil)
+}
+
+func ToTimePtrOpts(v interface{}, options *Options) *time.Time {
 	if v == nil {
 		return nil
 	}
-	ret := ToTime(v)
+	ret := ToTimeOpts(v, options)
 	return &ret
 }
-
-func ParseDuration(s string) (time.Duration, error) {
-	s = strings.TrimSpace(strings.ToLower(s))
-	if s == "" {
-		return 0, strconv.ErrSyntax
-	}
-	// support suffixes: ms, s, m, h, d
-	// try ms explicitly
-	if strings.HasSuffix(s, "ms") {
-		v := strings.TrimSuffix(s, "ms")
-		n, err := strconv.ParseInt(v, 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return time.Millisecond * time.Duration(n), nil
-	}
-	// single-char suffixes
-	unit := s[len(s)-1]
-	num := s[:len(s)-1]
-	n, err := strconv.ParseInt(num, 10, 64)
-	if err != nil {
-		return 0, err
-	}
-	switch unit {
-	case 's':
-		return time.Second * time.Duration(n), nil
-	case 'm':
-		return time.Minute * time.Duration(n), nil
-	case 'h':
-		return time.Hour * time.Duration(n), nil
-	case 'd':
-		return time.Hour * time.Duration(n*24), nil
-	default:
-		// if no recognized suffix, try to parse as plain milliseconds
-		n2, err2 := strconv.ParseInt(s, 10, 64)
-		if err2 != nil {
-			return 0, strconv.ErrSyntax
-		}
-		return time.Second * time.Duration(n2), nil
-	}
-}
@@ -0,0 +1,162 @@
+package tox
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// EpochUnit is the unit of numeric timestamps.
+type EpochUnit int
+
+const (
+	// EpochAuto guesses the unit from the magnitude of the number, anything below 1e11 is seconds, below 1e14
+	// milliseconds, below 1e17 microseconds and nanoseconds above that.
+	EpochAuto EpochUnit = iota
+	EpochSeconds
+	EpochMilliseconds
+	EpochMicroseconds
+	EpochNanoseconds
+)
+
+// DefaultTimeLayouts are the layouts tried when TimeOptions.Layouts is empty.  Fractional seconds are accepted after
+// the seconds field of any layout.
+var DefaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+}
+
+// TimeOptions controls how ToTime parses strings and numbers.
+type TimeOptions struct {
+	// Layouts are tried in order for strings that are not numbers, defaults to DefaultTimeLayouts.
+	Layouts []string
+	// Location is used for strings without a zone, defaults to UTC.
+	Location *time.Location
+	// EpochUnit is the unit of numbers and numeric strings, defaults to EpochAuto.
+	EpochUnit EpochUnit
+}
+
+var defaultTimeOptions atomic.Pointer[TimeOptions]
+
+// SetDefaultTimeOptions changes the options used by ToTime, ToTimePtr, IsTime and Object.GetTime.
+func SetDefaultTimeOptions(opts TimeOptions) {
+	defaultTimeOptions.Store(&opts)
+}
+
+// timeOptions picks the options to use, the ones passed in or the package defaults.
+func timeOptions(options *Options) *TimeOptions {
+	if options != nil && options.Time != nil {
+		return options.Time
+	}
+	if opts := defaultTimeOptions.Load(); opts != nil {
+		return opts
+	}
+	return &TimeOptions{}
+}
+
+func (o *TimeOptions) layouts() []string {
+	if len(o.Layouts) == 0 {
+		return DefaultTimeLayouts
+	}
+	return o.Layouts
+}
+
+func (o *TimeOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// ParseTime parses a timestamp string, numeric strings are epoch times in opts.EpochUnit and anything else is tried
+// against each of the layouts.  A nil opts uses the defaults.
+func ParseTime(s string, opts *TimeOptions) (time.Time, error) {
+	if opts == nil {
+		opts = timeOptions(nil)
+	}
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return epochTime(i, opts.EpochUnit), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return epochFloatTime(f, opts.EpochUnit)
+	}
+	return parseTimeLayouts(s, opts)
+}
+
+func parseTimeLayouts(s string, opts *TimeOptions) (time.Time, error) {
+	for _, layout := range opts.layouts() {
+		if t, err := time.ParseInLocation(layout, s, opts.location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, newConversionError(s, "time.Time", ErrSyntax)
+}
+
+func epochUnit(n float64, unit EpochUnit) EpochUnit {
+	if unit != EpochAuto {
+		return unit
+	}
+	n = math.Abs(n)
+	switch {
+	case n < 1e11:
+		return EpochSeconds
+	case n < 1e14:
+		return EpochMilliseconds
+	case n < 1e17:
+		return EpochMicroseconds
+	default:
+		return EpochNanoseconds
+	}
+}
+
+func epochTime(n int64, unit EpochUnit) time.Time {
+	switch epochUnit(float64(n), unit) {
+	case EpochMilliseconds:
+		return time.UnixMilli(n)
+	case EpochMicroseconds:
+		return time.UnixMicro(n)
+	case EpochNanoseconds:
+		return time.Unix(0, n)
+	default:
+		return time.Unix(n, 0)
+	}
+}
+
+func epochFloatTime(f float64, unit EpochUnit) (time.Time, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return time.Time{}, newConversionError(f, "time.Time", ErrNaN)
+	}
+	// seconds per unit
+	var scale float64
+	switch epochUnit(f, unit) {
+	case EpochMilliseconds:
+		scale = 1e-3
+	case EpochMicroseconds:
+		scale = 1e-6
+	case EpochNanoseconds:
+		scale = 1e-9
+	default:
+		scale = 1
+	}
+	secs := f * scale
+	if math.Abs(secs) >= 1<<62 {
+		return time.Time{}, newConversionError(f, "time.Time", ErrOverflow)
+	}
+	whole := math.Floor(secs)
+	return time.Unix(int64(whole), int64(math.Round((secs-whole)*1e9))), nil
+}
@@ -0,0 +1,50 @@
+package tox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToTimeFormats(t *testing.T) {
+	utc := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	assert.True(t, utc.Equal(ToTime("2024-01-02T15:04:05Z")))
+	assert.True(t, utc.Equal(ToTime("2024-01-02 15:04:05")))
+	assert.True(t, utc.Equal(ToTime("2024-01-02T15:04:05")))
+	assert.True(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Equal(ToTime("2024-01-02")))
+	assert.True(t, utc.Add(250*time.Millisecond).Equal(ToTime("2024-01-02 15:04:05.25")))
+
+	// epochs in every unit
+	assert.True(t, utc.Equal(ToTime(utc.Unix())))
+	assert.True(t, utc.Equal(ToTime(utc.UnixMilli())))
+	assert.True(t, utc.Equal(ToTime(utc.UnixMicro())))
+	assert.True(t, utc.Equal(ToTime(utc.UnixNano())))
+	assert.True(t, utc.Equal(ToTime(ToString(utc.UnixMilli()))))
+	assert.True(t, utc.Add(500*time.Millisecond).Equal(ToTime(float64(utc.Unix())+0.5)))
+	assert.True(t, utc.Add(500*time.Millisecond).Equal(ToTime("1704207845.5")))
+
+	_, err := ToTimeE("not a time")
+	assert.ErrorIs(t, err, ErrSyntax)
+
+	ny, _ := time.LoadLocation("America/New_York")
+	opts := &Options{Time: &TimeOptions{Location: ny, Layouts: []string{"01/02/2006 15:04"}, EpochUnit: EpochMilliseconds}}
+	assert.True(t, time.Date(2024, 1, 2, 15, 4, 0, 0, ny).Equal(ToTimeOpts("01/02/2024 15:04", opts)))
+	assert.True(t, time.Unix(5, 0).Equal(ToTimeOpts(5000, opts)))
+	assert.True(t, ToTimeOpts("2024-01-02", opts).IsZero())
+
+	assert.True(t, IsTime("2024-01-02 15:04:05"))
+	assert.False(t, IsTime("1704207845"))
+	assert.False(t, IsTime(nil))
+}
+
+func TestDefaultTimeOptions(t *testing.T) {
+	defer SetDefaultTimeOptions(TimeOptions{})
+	SetDefaultTimeOptions(TimeOptions{Layouts: []string{"02.01.2006"}, EpochUnit: EpochSeconds})
+
+	o := Object{"when": "03.02.2024", "epoch": int64(1704207845000)}
+	assert.True(t, time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC).Equal(o.GetTime("when", time.Time{})))
+	assert.True(t, time.Unix(1704207845000, 0).Equal(o.GetTime("epoch", time.Time{})))
+	assert.True(t, IsTime("03.02.2024"))
+}
@@ -0,0 +1,276 @@
+package tox
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	objectType          = reflect.TypeOf(Object{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// basicTypes are the builtin types for each basic kind, used to strip the name from named types like
+// `type Celsius float64` before handing them to the typed converters.
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+// To converts v to T, if the conversion fails, it returns the zero value of T.
+func To[T any](v any) T {
+	t, _ := ToE[T](v)
+	return t
+}
+
+// ToE converts v to T, returning an error if the value cannot be converted.  Built in kinds use the matching ToXxxE
+// function, named types are converted through their underlying kind, pointers, slices and maps are converted element
+// by element and anything else is round tripped through JSON.
+func ToE[T any](v any) (T, error) {
+	var zero T
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	rv, err := convertTo(v, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return zero, err
+	}
+	if !rv.IsValid() {
+		return zero, nil
+	}
+	t, _ := rv.Interface().(T)
+	return t, nil
+}
+
+// basicValue returns v converted to the builtin type of its kind, so Celsius(1.5) becomes float64(1.5).
+func basicValue(v any) any {
+	if bv, ok := namedBasic(v); ok {
+		return bv
+	}
+	return v
+}
+
+// namedBasic converts a named type with a basic underlying kind to the builtin type, ok is false for anything else.
+func namedBasic(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if bt, found := basicTypes[rv.Kind()]; found && rv.Type() != bt {
+		return rv.Convert(bt).Interface(), true
+	}
+	return nil, false
+}
+
+func convertTo(v any, t reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, newConversionError(v, t.String(), ErrUnsupportedType)
+	}
+	src := reflect.ValueOf(v)
+	if src.Type() == t {
+		return src, nil
+	}
+	if cv, found, err := convertRegistered(v, t); found {
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return convertTo(cv, t)
+	}
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		return convertTo(src.Elem().Interface(), t)
+	}
+
+	switch t {
+	case timeType:
+		tm, err := ToTimeE(v)
+		return reflect.ValueOf(tm), err
+	case durationType:
+		d, err := ToDurationE(v)
+		return reflect.ValueOf(d), err
+	case objectType:
+		switch src.Kind() {
+		case reflect.Map, reflect.Struct:
+			if o := structToObject(v); o != nil {
+				return reflect.ValueOf(o), nil
+			}
+		}
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		var text []byte
+		switch tv := v.(type) {
+		case string:
+			text = []byte(tv)
+		case []byte:
+			text = tv
+		}
+		if text != nil {
+			ret := reflect.New(t)
+			if err := ret.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				return reflect.Value{}, newConversionError(v, t.String(), fmt.Errorf("%w: %v", ErrSyntax, err))
+			}
+			return ret.Elem(), nil
+		}
+	}
+
+	var out any
+	var err error
+	switch t.Kind() {
+	case reflect.Bool:
+		out, err = ToBoolE(basicValue(v))
+	case reflect.Int:
+		out, err = ToIntE(basicValue(v))
+	case reflect.Int8:
+		out, err = ToInt8E(basicValue(v))
+	case reflect.Int16:
+		out, err = ToInt16E(basicValue(v))
+	case reflect.Int32:
+		out, err = ToInt32E(basicValue(v))
+	case reflect.Int64:
+		out, err = ToInt64E(basicValue(v))
+	case reflect.Uint:
+		out, err = ToUintE(basicValue(v))
+	case reflect.Uint8:
+		out, err = ToUint8E(basicValue(v))
+	case reflect.Uint16:
+		out, err = ToUint16E(basicValue(v))
+	case reflect.Uint32:
+		out, err = ToUint32E(basicValue(v))
+	case reflect.Uint64:
+		out, err = ToUint64E(basicValue(v))
+	case reflect.Float32:
+		var f float64
+		f, err = ToFloat64E(basicValue(v))
+		if err == nil && !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			err = newConversionError(v, t.String(), ErrOverflow)
+		}
+		out = float32(f)
+	case reflect.Float64:
+		out, err = ToFloat64E(basicValue(v))
+	case reflect.String:
+		out, err = ToStringE(basicValue(v))
+	case reflect.Ptr:
+		if src.Kind() == reflect.Ptr && src.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		elem, err := convertTo(v, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(elem)
+		return p, nil
+	case reflect.Slice, reflect.Array:
+		return convertToList(v, t)
+	case reflect.Map:
+		if src.Kind() == reflect.Map {
+			return convertToMap(v, t)
+		}
+		return convertByJson(v, t)
+	case reflect.Interface:
+		if src.Type().Implements(t) {
+			ret := reflect.New(t).Elem()
+			ret.Set(src)
+			return ret, nil
+		}
+		return reflect.Value{}, newConversionError(v, t.String(), ErrUnsupportedType)
+	default:
+		return convertByJson(v, t)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(out).Convert(t), nil
+}
+
+func convertToList(v any, t reflect.Type) (reflect.Value, error) {
+	src := reflect.ValueOf(v)
+	if t.Elem().Kind() == reflect.Uint8 && src.Kind() == reflect.String && t.Kind() == reflect.Slice {
+		return reflect.ValueOf([]byte(src.String())).Convert(t), nil
+	}
+	var items []any
+	switch src.Kind() {
+	case reflect.Slice, reflect.Array:
+		items = make([]any, src.Len())
+		for i := 0; i < src.Len(); i++ {
+			items[i] = src.Index(i).Interface()
+		}
+	default:
+		// like ToIntArray, a single value becomes a one element slice
+		items = []any{v}
+	}
+	var ret reflect.Value
+	if t.Kind() == reflect.Array {
+		ret = reflect.New(t).Elem()
+		if len(items) > t.Len() {
+			return reflect.Value{}, newConversionError(v, t.String(), ErrOverflow)
+		}
+	} else {
+		ret = reflect.MakeSlice(t, len(items), len(items))
+	}
+	for i, item := range items {
+		ev, err := convertTo(item, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ret.Index(i).Set(ev)
+	}
+	return ret, nil
+}
+
+func convertToMap(v any, t reflect.Type) (reflect.Value, error) {
+	src := reflect.ValueOf(v)
+	ret := reflect.MakeMapWithSize(t, src.Len())
+	iter := src.MapRange()
+	for iter.Next() {
+		kv, err := convertTo(iter.Key().Interface(), t.Key())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ev, err := convertTo(iter.Value().Interface(), t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ret.SetMapIndex(kv, ev)
+	}
+	return ret, nil
+}
+
+// convertByJson handles structs the same way Object.ToStruct does, by marshalling the value and unmarshalling it into
+// the target type.
+func convertByJson(v any, t reflect.Type) (reflect.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return reflect.Value{}, newConversionError(v, t.String(), ErrUnsupportedType)
+	}
+	ret := reflect.New(t)
+	if err = json.Unmarshal(b, ret.Interface()); err != nil {
+		return reflect.Value{}, newConversionError(v, t.String(), ErrSyntax)
+	}
+	return ret.Elem(), nil
+}
@@ -0,0 +1,52 @@
+package tox
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type celsius float64
+
+type toTarget struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestTo(t *testing.T) {
+	assert.Equal(t, 12, To[int]("12"))
+	assert.Equal(t, "12", To[string](12))
+	assert.Equal(t, 1.5, To[float64]("1.5"))
+	assert.Equal(t, true, To[bool](1))
+	assert.Equal(t, celsius(21.5), To[celsius]("21.5"))
+	assert.Equal(t, 21.5, To[float64](celsius(21.5)))
+	assert.Equal(t, time.Unix(10, 0), To[time.Time](10))
+
+	p := To[*int]("7")
+	assert.NotNil(t, p)
+	assert.Equal(t, 7, *p)
+	assert.Nil(t, To[*int](nil))
+	assert.Nil(t, To[any](nil))
+	assert.Nil(t, To[error](nil))
+	assert.Nil(t, To[fmt.Stringer](nil))
+	_, nilErr := ToE[error](nil)
+	assert.NoError(t, nilErr)
+
+	assert.Equal(t, []int{1, 2, 3}, To[[]int]([]any{"1", 2.0, 3}))
+	assert.Equal(t, []celsius{1, 2}, To[[]celsius]([]string{"1", "2"}))
+	assert.Equal(t, []int{5}, To[[]int](5))
+	assert.Equal(t, map[string]int{"a": 1}, To[map[string]int](map[string]any{"a": "1"}))
+
+	s := To[toTarget](Object{"name": "x", "count": 3})
+	assert.Equal(t, toTarget{Name: "x", Count: 3}, s)
+	assert.Equal(t, Object{"name": "x", "count": 3}, To[Object](toTarget{Name: "x", Count: 3}))
+
+	_, err := ToE[int8](300)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = ToE[[]int]([]any{"1", "x"})
+	assert.ErrorIs(t, err, ErrSyntax)
+	_, err = ToE[int](nil)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
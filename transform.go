@@ -0,0 +1,201 @@
+package tox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrKeyCollision is returned when renaming keys would give two keys of the same map the same name.
+var ErrKeyCollision = errors.New("key collision")
+
+// Transformer is one step of Object.Transform.  Key, when set, renames every map key.  Value, when set, replaces
+// every value, or drops it when it returns false, and is given the path of the value before any keys were renamed.
+type Transformer struct {
+	Key   func(key string) string
+	Value func(path Path, value any) (any, bool)
+}
+
+// Transform returns a copy of the object with transformers applied in order to every key and value, walking nested
+// Objects and arrays once, children before their parents.  It returns an error wrapping ErrKeyCollision when two
+// keys of a map would get the same name.
+//
+//	o.Transform(tox.TrimStrings(), tox.NumbersFromStrings(), tox.DropNulls(), tox.SnakeCaseKeys())
+func (o Object) Transform(transformers ...Transformer) (Object, error) {
+	if o == nil {
+		return nil, nil
+	}
+	work := o.Clone()
+	var err error
+	work.WalkWith(WalkOptions{PostOrder: true}, func(path Path, value any) WalkAction {
+		if m, ok := mapOf(value); ok {
+			if err = renameKeys(m, path, transformers); err != nil {
+				return WalkStop
+			}
+		}
+		for _, t := range transformers {
+			if t.Value == nil {
+				continue
+			}
+			var keep bool
+			if value, keep = t.Value(path, value); !keep {
+				return WalkDelete
+			}
+		}
+		return WalkReplace(value)
+	})
+	if err == nil {
+		err = renameKeys(work, Path{}, transformers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return work, nil
+}
+
+// TransformInPlace applies transformers to the object as Transform does, leaving it unchanged on error.
+func (o Object) TransformInPlace(transformers ...Transformer) error {
+	work, err := o.Transform(transformers...)
+	if err != nil {
+		return err
+	}
+	for k := range o {
+		delete(o, k)
+	}
+	for k, v := range work {
+		o[k] = v
+	}
+	return nil
+}
+
+// renameKeys renames the keys of m, which is at path, with the Key of each transformer in turn.
+func renameKeys(m map[string]any, path Path, transformers []Transformer) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	renamed := make(map[string]string, len(keys))
+	from := make(map[string]string, len(keys))
+	for _, k := range keys {
+		nk := k
+		for _, t := range transformers {
+			if t.Key != nil {
+				nk = t.Key(nk)
+			}
+		}
+		if prev, found := from[nk]; found {
+			if len(path) > 0 {
+				return fmt.Errorf("%w: %q and %q both become %q at %s", ErrKeyCollision, prev, k, nk, path)
+			}
+			return fmt.Errorf("%w: %q and %q both become %q", ErrKeyCollision, prev, k, nk)
+		}
+		from[nk] = k
+		if nk != k {
+			renamed[k] = nk
+		}
+	}
+	values := make(map[string]any, len(renamed))
+	for k := range renamed {
+		values[k] = m[k]
+		delete(m, k)
+	}
+	for k, nk := range renamed {
+		m[nk] = values[k]
+	}
+	return nil
+}
+
+// TrimStrings trims leading and trailing white space from every string value.
+func TrimStrings() Transformer {
+	return Transformer{Value: func(path Path, value any) (any, bool) {
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(s), true
+		}
+		return value, true
+	}}
+}
+
+// NumbersFromStrings converts every string value that holds a finite number into that number, an int where
+// ToNumber gives one and a float64 otherwise.
+func NumbersFromStrings() Transformer {
+	return Transformer{Value: func(path Path, value any) (any, bool) {
+		s, ok := value.(string)
+		if !ok {
+			return value, true
+		}
+		if n := ToNumber(s); n != any(s) {
+			return n, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f, true
+		}
+		return value, true
+	}}
+}
+
+// RenameKeys renames every map key found in names, at any depth.
+func RenameKeys(names map[string]string) Transformer {
+	return Transformer{Key: func(key string) string {
+		if name, found := names[key]; found {
+			return name
+		}
+		return key
+	}}
+}
+
+// DropNulls drops nil values from maps.  Nil elements of arrays are kept so the other elements keep their indexes.
+func DropNulls() Transformer {
+	return Transformer{Value: func(path Path, value any) (any, bool) {
+		return value, value != nil || path[len(path)-1].IsIndex
+	}}
+}
+
+// SnakeCaseKeys converts every map key to snake_case with SnakeCase.
+func SnakeCaseKeys() Transformer {
+	return Transformer{Key: SnakeCase}
+}
+
+// KeyStyle is a naming convention for keys, as used by ConvertKeys.
+type KeyStyle int
+
+const (
+	// CamelKeys is camelCase, as in "deviceId".
+	CamelKeys KeyStyle = iota
+	// SnakeKeys is snake_case, as in "device_id".
+	SnakeKeys
+	// KebabKeys is kebab-case, as in "device-id".
+	KebabKeys
+	// PascalKeys is PascalCase, as in "DeviceId".
+	PascalKeys
+	// ScreamingSnakeKeys is SCREAMING_SNAKE_CASE, as in "DEVICE_ID".
+	ScreamingSnakeKeys
+)
+
+// Convert returns key in the style, or key unchanged for an unknown style.
+func (style KeyStyle) Convert(key string) string {
+	switch style {
+	case CamelKeys:
+		return CamelCase(key)
+	case SnakeKeys:
+		return SnakeCase(key)
+	case KebabKeys:
+		return KebabCase(key)
+	case PascalKeys:
+		return PascalCase(key)
+	case ScreamingSnakeKeys:
+		return ScreamingSnakeCase(key)
+	default:
+		return key
+	}
+}
+
+// ConvertKeys converts every key of the object, and of the Objects nested in it and in its arrays, to style.  It
+// returns an error wrapping ErrKeyCollision, leaving the object unchanged, when two keys of a map would become the
+// same, such as "deviceID" and "device_id" converted to SnakeKeys.
+func (o Object) ConvertKeys(style KeyStyle) error {
+	return o.TransformInPlace(Transformer{Key: style.Convert})
+}
@@ -0,0 +1,77 @@
+package tox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	o := Object{
+		"userName": "  ann ",
+		"Count":    " 12 ",
+		"ratio":    "0.5",
+		"nothing":  nil,
+		"Items":    []any{Object{"itemID": "7", "skip": nil}, nil, "NaN"},
+		"oldKey":   Object{"HTTPCode": "x1"},
+	}
+	out, err := o.Transform(TrimStrings(), NumbersFromStrings(), RenameKeys(map[string]string{"oldKey": "new"}),
+		DropNulls(), SnakeCaseKeys())
+	assert.NoError(t, err)
+	assert.Equal(t, Object{
+		"user_name": "ann",
+		"count":     12,
+		"ratio":     0.5,
+		"items":     []any{Object{"item_id": 7}, nil, "NaN"},
+		"new":       Object{"http_code": "x1"},
+	}, out)
+	assert.Equal(t, " 12 ", o["Count"], "Transform must not change the original")
+
+	out, err = o.Transform(NumbersFromStrings(), TrimStrings())
+	assert.NoError(t, err)
+	assert.Equal(t, "12", out["Count"], "transforms run in order")
+
+	upper := Transformer{Value: func(path Path, value any) (any, bool) {
+		if s, ok := value.(string); ok && path.String() == "Items[2]" {
+			return strings.ToUpper(s), true
+		}
+		return value, true
+	}}
+	assert.NoError(t, o.TransformInPlace(upper))
+	assert.Equal(t, "NAN", o["Items"].([]any)[2])
+
+	swap := Object{"a": 1, "b": 2}
+	assert.NoError(t, swap.TransformInPlace(RenameKeys(map[string]string{"a": "b", "b": "a"})))
+	assert.Equal(t, Object{"a": 2, "b": 1}, swap)
+
+	clash := Object{"x": Object{"userID": 1, "user_id": 2}}
+	_, err = clash.Transform(SnakeCaseKeys())
+	assert.ErrorIs(t, err, ErrKeyCollision)
+	assert.ErrorIs(t, clash.TransformInPlace(SnakeCaseKeys()), ErrKeyCollision)
+	assert.Equal(t, Object{"x": Object{"userID": 1, "user_id": 2}}, clash)
+}
+
+func TestConvertKeys(t *testing.T) {
+	o := Object{"deviceID": 1, "sensorData": Object{"lastValue": 2}, "readings": []any{Object{"rawValue": 3}}}
+	assert.NoError(t, o.ConvertKeys(SnakeKeys))
+	assert.Equal(t, Object{"device_id": 1, "sensor_data": Object{"last_value": 2}, "readings": []any{Object{"raw_value": 3}}}, o)
+
+	assert.NoError(t, o.ConvertKeys(PascalKeys))
+	assert.Equal(t, Object{"DeviceId": 1, "SensorData": Object{"LastValue": 2}, "Readings": []any{Object{"RawValue": 3}}}, o)
+
+	assert.NoError(t, o.ConvertKeys(CamelKeys))
+	assert.Equal(t, Object{"deviceId": 1, "sensorData": Object{"lastValue": 2}, "readings": []any{Object{"rawValue": 3}}}, o)
+
+	for style, key := range map[KeyStyle]string{KebabKeys: "device-id", ScreamingSnakeKeys: "DEVICE_ID"} {
+		assert.Equal(t, key, style.Convert("deviceID"))
+	}
+
+	mongo := Object{"_id": 1, "__v": 0, "$set": Object{"lastSeen": 2, "deviceIDs": []any{"a"}}}
+	assert.NoError(t, mongo.ConvertKeys(SnakeKeys))
+	assert.Equal(t, Object{"_id": 1, "__v": 0, "$set": Object{"last_seen": 2, "device_ids": []any{"a"}}}, mongo)
+
+	clash := Object{"list": []any{Object{"deviceID": 1, "device_id": 2}}}
+	assert.ErrorIs(t, clash.ConvertKeys(SnakeKeys), ErrKeyCollision)
+	assert.Equal(t, Object{"list": []any{Object{"deviceID": 1, "device_id": 2}}}, clash)
+}
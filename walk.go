@@ -0,0 +1,235 @@
+package tox
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathSegment is one step of a Path, a map key or, when IsIndex is set, an array index.
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// Path is the location of a value below an Object, as passed to the function given to Walk.
+type Path []PathSegment
+
+// String returns the path in the dotted form Get and Set accept, such as "a.b[0].c", with keys escaped as JoinPath
+// does.
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, seg := range p {
+		switch {
+		case seg.IsIndex:
+			sb.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+		case i > 0:
+			sb.WriteString("." + escapePathKey(seg.Key, "."))
+		default:
+			sb.WriteString(escapePathKey(seg.Key, "."))
+		}
+	}
+	return sb.String()
+}
+
+func (p Path) child(seg PathSegment) Path {
+	return append(p[:len(p):len(p)], seg)
+}
+
+type walkOp int
+
+const (
+	walkContinue walkOp = iota
+	walkSkip
+	walkReplace
+	walkDelete
+	walkStop
+)
+
+// WalkAction tells Walk what to do after visiting a value.
+type WalkAction struct {
+	op    walkOp
+	value any
+}
+
+var (
+	// WalkContinue goes on walking, into the children of the value when it is an Object or array.
+	WalkContinue = WalkAction{op: walkContinue}
+	// WalkSkip goes on walking without visiting the children of the value.  After them, it is the same as WalkContinue.
+	WalkSkip = WalkAction{op: walkSkip}
+	// WalkDelete removes the value from its map or array.  Later elements of an array keep the paths they had when the
+	// walk began.
+	WalkDelete = WalkAction{op: walkDelete}
+	// WalkStop ends the walk, leaving the values not yet visited as they are.
+	WalkStop = WalkAction{op: walkStop}
+)
+
+// WalkReplace replaces the value with v.  Before the children of the value, the children of v are walked instead.
+func WalkReplace(v any) WalkAction {
+	return WalkAction{op: walkReplace, value: v}
+}
+
+// WalkOptions controls WalkWith.
+type WalkOptions struct {
+	// PostOrder visits the children of a value before the value itself, rather than after.
+	PostOrder bool
+}
+
+// Walk calls fn for every value below the object, parents before children, with map keys in sorted order.  It descends
+// into maps with string keys, slices and arrays, and changes them in place as fn asks.  A slice or array may be
+// replaced by a new one when an element is deleted or does not fit its element type, as is a typed map when a value
+// does not fit.
+func (o Object) Walk(fn func(path Path, value any) WalkAction) {
+	o.WalkWith(WalkOptions{}, fn)
+}
+
+// WalkWith is Walk as options say.
+func (o Object) WalkWith(options WalkOptions, fn func(path Path, value any) WalkAction) {
+	if o == nil {
+		return
+	}
+	w := walker{fn: fn, post: options.PostOrder}
+	w.children(Path{}, o)
+}
+
+type walker struct {
+	fn      func(Path, any) WalkAction
+	post    bool
+	stopped bool
+}
+
+// walk visits v at path, returning the value to keep in its place, false when it was deleted, and whether it changed.
+func (w *walker) walk(path Path, v any) (any, bool, bool) {
+	changed := false
+	if !w.post {
+		action := w.fn(path, v)
+		switch action.op {
+		case walkStop:
+			w.stopped = true
+			return v, true, false
+		case walkDelete:
+			return nil, false, true
+		case walkSkip:
+			return v, true, false
+		case walkReplace:
+			v, changed = action.value, true
+		}
+	}
+	if nv, ok := w.children(path, v); ok {
+		v, changed = nv, true
+	}
+	if w.post && !w.stopped {
+		action := w.fn(path, v)
+		switch action.op {
+		case walkStop:
+			w.stopped = true
+		case walkDelete:
+			return nil, false, true
+		case walkReplace:
+			v, changed = action.value, true
+		}
+	}
+	return v, true, changed
+}
+
+// children walks the children of v, returning v, or the value that replaced it, and whether any of them changed.
+func (w *walker) children(path Path, v any) (any, bool) {
+	if m, ok := mapOf(v); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		changed := false
+		for _, k := range keys {
+			if w.stopped {
+				break
+			}
+			child, keep, ch := w.walk(path.child(PathSegment{Key: k}), m[k])
+			if !keep {
+				delete(m, k)
+			} else if ch {
+				m[k] = child
+			}
+			changed = changed || ch
+		}
+		return v, changed
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case isStringMap(rv):
+		return w.typedMap(path, rv)
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		return w.list(path, rv)
+	}
+	return v, false
+}
+
+func (w *walker) typedMap(path Path, rv reflect.Value) (any, bool) {
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	changed := false
+	for _, k := range keys {
+		if w.stopped {
+			break
+		}
+		key := reflect.ValueOf(k).Convert(rv.Type().Key())
+		child, keep, ch := w.walk(path.child(PathSegment{Key: k}), rv.MapIndex(key).Interface())
+		if !ch {
+			continue
+		}
+		changed = true
+		cv := reflect.ValueOf(child)
+		switch {
+		case !keep:
+			rv.SetMapIndex(key, reflect.Value{})
+		case child == nil && canBeNil(rv.Type().Elem().Kind()):
+			rv.SetMapIndex(key, reflect.Zero(rv.Type().Elem()))
+		case cv.IsValid() && cv.Type().AssignableTo(rv.Type().Elem()):
+			rv.SetMapIndex(key, cv)
+		default:
+			m := make(map[string]any, rv.Len())
+			for _, mk := range rv.MapKeys() {
+				m[mk.String()] = rv.MapIndex(mk).Interface()
+			}
+			m[k] = child
+			rv = reflect.ValueOf(m)
+		}
+	}
+	return rv.Interface(), changed
+}
+
+func (w *walker) list(path Path, rv reflect.Value) (any, bool) {
+	rv = addressable(rv)
+	v := rv.Interface()
+	var deleted []int
+	changed := false
+	for i := 0; i < rv.Len() && !w.stopped; i++ {
+		child, keep, ch := w.walk(path.child(PathSegment{Index: i, IsIndex: true}), rv.Index(i).Interface())
+		if !keep {
+			deleted = append(deleted, i)
+		} else if ch {
+			v = setIndex(rv, i, child)
+			rv = addressable(reflect.ValueOf(v))
+		}
+		changed = changed || ch
+	}
+	if len(deleted) == 0 {
+		return v, changed
+	}
+	ret := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), 0, rv.Len()-len(deleted))
+	for i := 0; i < rv.Len(); i++ {
+		if len(deleted) > 0 && deleted[0] == i {
+			deleted = deleted[1:]
+			continue
+		}
+		ret = reflect.Append(ret, rv.Index(i))
+	}
+	return ret.Interface(), true
+}
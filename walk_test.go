@@ -0,0 +1,90 @@
+package tox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	o := Object{"a": 1, "b": []any{2, Object{"c": 3}}, "d.e": Object{"f": 4}}
+	var pre, post []string
+	o.Walk(func(path Path, value any) WalkAction {
+		pre = append(pre, path.String())
+		return WalkContinue
+	})
+	o.WalkWith(WalkOptions{PostOrder: true}, func(path Path, value any) WalkAction {
+		post = append(post, path.String())
+		return WalkContinue
+	})
+	assert.Equal(t, []string{"a", "b", "b[0]", "b[1]", "b[1].c", `d\.e`, `d\.e.f`}, pre)
+	assert.Equal(t, []string{"a", "b[0]", "b[1].c", "b[1]", "b", `d\.e.f`, `d\.e`}, post)
+	assert.Equal(t, Path{{Key: "b"}, {Index: 1, IsIndex: true}, {Key: "c"}}, func() Path {
+		var found Path
+		o.Walk(func(path Path, value any) WalkAction {
+			if value == 3 {
+				found = path
+				return WalkStop
+			}
+			return WalkContinue
+		})
+		return found
+	}())
+
+	pre = nil
+	o.Walk(func(path Path, value any) WalkAction {
+		pre = append(pre, path.String())
+		if len(path) == 1 {
+			return WalkSkip
+		}
+		return WalkContinue
+	})
+	assert.Equal(t, []string{"a", "b", `d\.e`}, pre)
+
+	o.Walk(func(path Path, value any) WalkAction {
+		switch value {
+		case 1:
+			return WalkReplace(Object{"x": 10})
+		case 10:
+			return WalkReplace(11)
+		case 2, 4:
+			return WalkDelete
+		}
+		return WalkContinue
+	})
+	assert.Equal(t, Object{"a": Object{"x": 11}, "b": []any{Object{"c": 3}}, "d.e": Object{}}, o)
+
+	typed := Object{"s": []int{1, 2, 3}, "r": [2]float64{1, math.NaN()}, "m": map[string]int{"x": 1, "y": 2}}
+	typed.WalkWith(WalkOptions{PostOrder: true}, func(path Path, value any) WalkAction {
+		switch value {
+		case 2:
+			return WalkDelete
+		case 3:
+			return WalkReplace("three")
+		}
+		if isNan(value) {
+			return WalkReplace(2.0)
+		}
+		return WalkContinue
+	})
+	assert.Equal(t, Object{"s": []any{1, "three"}, "r": [2]float64{1, 2}, "m": map[string]int{"x": 1}}, typed)
+
+	count := 0
+	Object{"a": []any{1, 2}, "b": Object{"c": 3}}.Walk(func(path Path, value any) WalkAction {
+		count++
+		return WalkStop
+	})
+	assert.Equal(t, 1, count)
+}
+
+func TestFieldCount(t *testing.T) {
+	assert.Equal(t, 0, Object(nil).FieldCount())
+	assert.Equal(t, 6, Object{"a": 1, "b": []any{2, 3}, "c": Object{"d": []string{"e", "f"}, "g": Object{}}, "h": nil}.FieldCount())
+}
+
+func TestRemoveNaNNested(t *testing.T) {
+	o := Object{"a": []any{1.0, math.NaN(), Object{"b": math.NaN(), "c": 2}}, "d": map[string]float64{"e": math.NaN(), "f": 1}}
+	o.RemoveNaN()
+	assert.Equal(t, Object{"a": []any{1.0, 0.0, map[string]any{"c": 2}}, "d": map[string]any{"f": 1.0}}, o)
+}